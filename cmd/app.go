@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"main/internal/config"
 	"main/pkg"
 	"main/pkg/handler"
+	"net/http"
 
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
@@ -13,11 +15,25 @@ import (
 
 func Run() any {
 	return func(
+		lc fx.Lifecycle,
 		env config.Env,
 		logger pkg.Logger,
 		handler handler.RequestHandler,
 	) {
-		logger.Fatal(handler.Run(fmt.Sprint(env.ServerAddress, ":", env.Port)))
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					err := handler.Run(fmt.Sprint(env.ServerAddress, ":", env.Port))
+					if err != nil && !errors.Is(err, http.ErrServerClosed) {
+						logger.Fatal(err)
+					}
+				}()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				return handler.Shutdown(ctx)
+			},
+		})
 	}
 }
 
@@ -29,8 +45,16 @@ func StartApp() error {
 		}),
 		fx.Invoke(Run()),
 	)
-	ctx := context.Background()
 	app := fx.New(CommonModules, opts)
-	err := app.Start(ctx)
-	return err
+	if err := app.Start(context.Background()); err != nil {
+		return err
+	}
+
+	// Wait for SIGINT/SIGTERM and run the OnStop hooks so background work is flushed
+	signal := <-app.Wait()
+	logger.Infof("Received %s, shutting down", signal.Signal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), app.StopTimeout())
+	defer cancel()
+	return app.Stop(ctx)
 }
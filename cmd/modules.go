@@ -4,6 +4,7 @@ import (
 	"main/internal/application/api"
 	"main/internal/config"
 	"main/internal/domain/alerts"
+	"main/internal/domain/anomalies"
 	"main/internal/domain/events"
 	"main/internal/infrastructure/database"
 	"main/internal/infrastructure/kubernetes"
@@ -24,4 +25,5 @@ var CommonModules = fx.Options(
 	events.Module,
 	database.Module,
 	alerts.Module,
+	anomalies.Module,
 )
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"main/internal/domain/anomalies"
+	"main/pkg"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnomalyController struct {
+	logger         pkg.Logger
+	anomalyService *anomalies.AnomalyService
+}
+
+func NewAnomalyController(logger pkg.Logger, anomalyService *anomalies.AnomalyService) *AnomalyController {
+	return &AnomalyController{
+		logger:         logger,
+		anomalyService: anomalyService,
+	}
+}
+
+// GetAnomalies runs the event rate anomaly detection, the configured window,
+// threshold and minimum occurrences can be overridden per request
+func (c *AnomalyController) GetAnomalies(ctx *gin.Context) {
+	options := c.anomalyService.Defaults()
+
+	for _, value := range ctx.QueryArray("namespace") {
+		for _, namespace := range strings.Split(value, ",") {
+			if namespace = strings.TrimSpace(namespace); namespace != "" {
+				options.Namespaces = append(options.Namespaces, namespace)
+			}
+		}
+	}
+
+	if windowStr := ctx.Query("window"); windowStr != "" {
+		window, err := time.ParseDuration(windowStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid window parameter"})
+			return
+		}
+		options.Window = window
+	}
+
+	if thresholdStr := ctx.Query("threshold"); thresholdStr != "" {
+		threshold, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || threshold <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid threshold parameter"})
+			return
+		}
+		options.Threshold = threshold
+	}
+
+	if minOccurrencesStr := ctx.Query("min_occurrences"); minOccurrencesStr != "" {
+		minOccurrences, err := strconv.ParseInt(minOccurrencesStr, 10, 64)
+		if err != nil || minOccurrences <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid min_occurrences parameter"})
+			return
+		}
+		options.MinOccurrences = minOccurrences
+	}
+
+	found, err := c.anomalyService.Detect(options)
+	if errors.Is(err, anomalies.ErrInvalidWindow) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.logger.Errorf("failed to detect event anomalies: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to detect event anomalies"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"anomalies":       found,
+		"total":           len(found),
+		"window":          options.Window.String(),
+		"threshold":       options.Threshold,
+		"min_occurrences": options.MinOccurrences,
+	})
+}
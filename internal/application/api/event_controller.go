@@ -1,49 +1,185 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"main/internal/config"
 	"main/internal/domain/events"
+	"main/internal/domain/leadership"
 	"main/pkg"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
 type EventController struct {
 	logger       pkg.Logger
-	eventService events.EventService
+	eventService *events.EventService
+	leader       leadership.Elector
+	upgrader     websocket.Upgrader
 }
 
-func NewEventController(logger pkg.Logger, eventService events.EventService) *EventController {
+func NewEventController(env config.Env, logger pkg.Logger, eventService *events.EventService, leader leadership.Elector) *EventController {
 	return &EventController{
 		logger:       logger,
 		eventService: eventService,
+		leader:       leader,
+		upgrader:     newWebsocketUpgrader(env.WebsocketAllowedOrigins),
 	}
 }
 
 func (c *EventController) ListEvents(ctx *gin.Context) {
-	namespace := ctx.DefaultQuery("namespace", "default")
-	eventType := ctx.Query("type")
-	limitStr := ctx.DefaultQuery("limit", "100")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		c.logger.Errorf("invalid limit parameter: %s", limitStr)
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+	filter, err := parseEventFilter(ctx)
+	if err != nil {
+		c.logger.Errorf("invalid event filter: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	foundEvents, err := c.eventService.GetEvents(namespace, eventType, limit)
+	page, err := c.eventService.ListEvents(filter)
 	if err != nil {
 		c.logger.Errorf("failed to list events: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list events"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"events": foundEvents,
-		"total":  len(foundEvents),
+	ctx.JSON(http.StatusOK, page)
+}
+
+// maxStatsBuckets keeps a single histogram request from generating an unbounded series
+const maxStatsBuckets = 2000
+
+func (c *EventController) GetEventStats(ctx *gin.Context) {
+	filter, err := parseEventFilter(ctx)
+	if err != nil {
+		c.logger.Errorf("invalid event filter: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Cursor = nil
+
+	if filter.Until == nil {
+		until := time.Now().UTC()
+		filter.Until = &until
+	}
+	if filter.Since == nil {
+		since := filter.Until.Add(-24 * time.Hour)
+		filter.Since = &since
+	}
+	if !filter.Since.Before(*filter.Until) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "since must be before until"})
+		return
+	}
+
+	intervalStr := ctx.DefaultQuery("interval", "1h")
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil || interval < time.Second {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid interval duration"})
+		return
+	}
+	if filter.Until.Sub(*filter.Since)/interval > maxStatsBuckets {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "interval is too small for the requested range"})
+		return
+	}
+
+	groupBy := events.StatsGroupBy(ctx.DefaultQuery("group_by", string(events.StatsGroupByType)))
+	if !groupBy.IsValid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_by parameter"})
+		return
+	}
+
+	topStr := ctx.DefaultQuery("top", "10")
+	top, err := strconv.Atoi(topStr)
+	if err != nil || top < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid top parameter"})
+		return
+	}
+
+	stats, err := c.eventService.GetEventStats(events.EventStatsQuery{
+		Filter:   filter,
+		Interval: interval,
+		GroupBy:  groupBy,
+		Top:      top,
 	})
+	if err != nil {
+		c.logger.Errorf("failed to get event stats: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get event stats"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, stats)
+}
+
+// parseEventFilter reads the list filters from the query string.
+// Namespaces can be passed as repeated or comma separated "namespace" params,
+// "all_namespaces=true" removes the namespace filter completely.
+func parseEventFilter(ctx *gin.Context) (events.EventFilter, error) {
+	filter := events.EventFilter{
+		Type:         ctx.Query("type"),
+		Reason:       ctx.Query("reason"),
+		InvolvedKind: ctx.Query("kind"),
+		InvolvedName: ctx.Query("name"),
+		OwnerKind:    ctx.Query("owner_kind"),
+		OwnerName:    ctx.Query("owner_name"),
+		Source:       ctx.Query("source"),
+	}
+
+	if ctx.Query("all_namespaces") != "true" {
+		for _, value := range ctx.QueryArray("namespace") {
+			for _, namespace := range strings.Split(value, ",") {
+				if namespace = strings.TrimSpace(namespace); namespace != "" {
+					filter.Namespaces = append(filter.Namespaces, namespace)
+				}
+			}
+		}
+		if len(filter.Namespaces) == 0 {
+			filter.Namespaces = []string{"default"}
+		}
+	}
+
+	limitStr := ctx.DefaultQuery("limit", "100")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		return filter, fmt.Errorf("invalid limit parameter")
+	}
+	filter.Limit = limit
+
+	if sinceStr := ctx.Query("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since time format")
+		}
+		filter.Since = &since
+	}
+
+	if untilStr := ctx.Query("until"); untilStr != "" {
+		until, err := time.Parse(time.RFC3339, untilStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid until time format")
+		}
+		filter.Until = &until
+	}
+
+	switch order := events.SortOrder(ctx.DefaultQuery("order", string(events.SortOrderDesc))); order {
+	case events.SortOrderAsc, events.SortOrderDesc:
+		filter.Order = order
+	default:
+		return filter, fmt.Errorf("invalid order parameter")
+	}
+
+	if cursorStr := ctx.Query("cursor"); cursorStr != "" {
+		cursor, err := events.DecodeEventCursor(cursorStr)
+		if err != nil {
+			return filter, err
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, nil
 }
 
 func (c *EventController) GetWatchedNamespaces(ctx *gin.Context) {
@@ -60,16 +196,59 @@ func (c *EventController) GetWatchedNamespaces(ctx *gin.Context) {
 	})
 }
 
+func (c *EventController) GetWatchedNamespacesStatus(ctx *gin.Context) {
+	statuses, err := c.eventService.GetWatcherStatuses()
+	if err != nil {
+		c.logger.Errorf("failed to get watcher statuses: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get watcher statuses"})
+		return
+	}
+
+	// Every replica reports its own watchers, only the leader runs them
+	status := c.leader.Status()
+	ctx.JSON(http.StatusOK, gin.H{
+		"watchers":  statuses,
+		"total":     len(statuses),
+		"replica":   status.Identity,
+		"leader":    status.Leader,
+		"is_leader": status.IsLeader,
+	})
+}
+
+type watchNamespaceRequest struct {
+	Namespace string `json:"namespace"`
+}
+
 func (c *EventController) AddWatchedNamespace(ctx *gin.Context) {
 	namespace := ctx.Query("namespace")
+	if namespace == "" && ctx.Request.ContentLength != 0 {
+		var request watchNamespaceRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+		namespace = request.Namespace
+	}
 
-	if err := c.eventService.AddNamespaceToWatch(ctx, namespace); err != nil {
+	backfilled, err := c.eventService.AddNamespaceToWatch(ctx.Request.Context(), namespace)
+	if errors.Is(err, events.ErrInvalidNamespace) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "namespace parameter is required"})
+		return
+	}
+	if errors.Is(err, events.ErrNamespaceNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.logger.Errorf("failed to add namespace to watch: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add namespace to watch"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "namespace added to watch list"})
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":    "namespace added to watch list",
+		"backfilled": backfilled,
+	})
 }
 
 func (c *EventController) RemoveWatchedNamespace(ctx *gin.Context) {
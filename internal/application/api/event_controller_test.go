@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"main/internal/domain/events"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestContext(target string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", target, nil)
+	return ctx
+}
+
+func TestParseEventFilterNamespaces(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		namespaces []string
+	}{
+		{name: "default namespace", query: "", namespaces: []string{"default"}},
+		{name: "single namespace", query: "namespace=kube-system", namespaces: []string{"kube-system"}},
+		{name: "repeated namespaces", query: "namespace=a&namespace=b", namespaces: []string{"a", "b"}},
+		{name: "comma separated namespaces", query: "namespace=a,%20b,,c", namespaces: []string{"a", "b", "c"}},
+		{name: "all namespaces", query: "all_namespaces=true", namespaces: nil},
+		{name: "all namespaces ignores namespace", query: "all_namespaces=true&namespace=a", namespaces: nil},
+		{name: "all namespaces must be true", query: "all_namespaces=1&namespace=a", namespaces: []string{"a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := parseEventFilter(newTestContext("/api/events?" + test.query))
+			if err != nil {
+				t.Fatalf("failed to parse filter: %v", err)
+			}
+			if !reflect.DeepEqual(filter.Namespaces, test.namespaces) {
+				t.Errorf("namespaces = %q, want %q", filter.Namespaces, test.namespaces)
+			}
+		})
+	}
+}
+
+func TestParseEventFilter(t *testing.T) {
+	cursor := events.EventCursor{LastTimestamp: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), ID: "id"}
+
+	filter, err := parseEventFilter(newTestContext("/api/events?type=Warning&reason=BackOff&kind=Pod&name=api-0" +
+		"&owner_kind=Deployment&owner_name=api&source=synthetic&limit=20&order=asc" +
+		"&since=2024-03-01T00:00:00Z&until=2024-03-02T00:00:00Z&cursor=" + cursor.Encode()))
+	if err != nil {
+		t.Fatalf("failed to parse filter: %v", err)
+	}
+
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	want := events.EventFilter{
+		Namespaces:   []string{"default"},
+		Type:         "Warning",
+		Reason:       "BackOff",
+		InvolvedKind: "Pod",
+		InvolvedName: "api-0",
+		OwnerKind:    "Deployment",
+		OwnerName:    "api",
+		Source:       "synthetic",
+		Since:        &since,
+		Until:        &until,
+		Order:        events.SortOrderAsc,
+		Limit:        20,
+		Cursor:       &cursor,
+	}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("filter = %+v, want %+v", filter, want)
+	}
+}
+
+func TestParseEventFilterRejectsInvalidParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "zero limit", query: "limit=0"},
+		{name: "negative limit", query: "limit=-1"},
+		{name: "non numeric limit", query: "limit=ten"},
+		{name: "since", query: "since=yesterday"},
+		{name: "until", query: "until=2024-03-01"},
+		{name: "order", query: "order=random"},
+		{name: "cursor", query: "cursor=broken"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseEventFilter(newTestContext("/api/events?" + test.query)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseEventFilterCursorError(t *testing.T) {
+	_, err := parseEventFilter(newTestContext("/api/events?cursor=broken"))
+	if !errors.Is(err, events.ErrInvalidCursor) {
+		t.Errorf("error = %v, want %v", err, events.ErrInvalidCursor)
+	}
+}
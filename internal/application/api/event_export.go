@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"main/internal/domain/events"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+)
+
+var eventCSVHeader = []string{
+	"id", "namespace", "name", "reason", "message", "type",
+	"involved_object", "first_timestamp", "last_timestamp", "count", "source",
+	"owner_kind", "owner_name",
+}
+
+// ExportEvents streams the events matching the list filters as CSV or NDJSON.
+// The limit is not applied unless it's passed explicitly, the response is gzipped
+// when the client accepts it.
+func (c *EventController) ExportEvents(ctx *gin.Context) {
+	filter, err := parseEventFilter(ctx)
+	if err != nil {
+		c.logger.Errorf("invalid event filter: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if ctx.Query("limit") == "" {
+		filter.Limit = 0
+	}
+
+	format := ctx.DefaultQuery("format", exportFormatNDJSON)
+	var contentType string
+	switch format {
+	case exportFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	case exportFormatNDJSON:
+		contentType = "application/x-ndjson"
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid format parameter"})
+		return
+	}
+
+	filename := fmt.Sprintf("events-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Header("X-Accel-Buffering", "no")
+
+	var output io.Writer = ctx.Writer
+	if strings.Contains(ctx.GetHeader("Accept-Encoding"), "gzip") {
+		ctx.Header("Content-Encoding", "gzip")
+		ctx.Header("Vary", "Accept-Encoding")
+		gzipWriter := gzip.NewWriter(ctx.Writer)
+		defer gzipWriter.Close()
+		output = gzipWriter
+	}
+	ctx.Status(http.StatusOK)
+
+	buffered := bufio.NewWriter(output)
+	defer buffered.Flush()
+
+	var writeEvent func(events.Event) error
+	switch format {
+	case exportFormatCSV:
+		csvWriter := csv.NewWriter(buffered)
+		// The header is flushed right away so an export without rows still has it
+		csvWriter.Write(eventCSVHeader)
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			c.logger.Errorf("failed to write events csv header: %v", err)
+			return
+		}
+		writeEvent = func(event events.Event) error {
+			csvWriter.Write([]string{
+				event.ID,
+				event.Namespace,
+				event.Name,
+				event.Reason,
+				event.Message,
+				event.Type,
+				event.InvolvedObject,
+				event.FirstTimestamp.Format(time.RFC3339),
+				event.LastTimestamp.Format(time.RFC3339),
+				strconv.Itoa(int(event.Count)),
+				event.Source,
+				event.OwnerKind,
+				event.OwnerName,
+			})
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	case exportFormatNDJSON:
+		encoder := json.NewEncoder(buffered)
+		writeEvent = func(event events.Event) error {
+			return encoder.Encode(event)
+		}
+	}
+
+	// The status is already sent, errors past this point can only be logged
+	// and end the response early
+	if err := c.eventService.ExportEvents(filter, writeEvent); err != nil {
+		c.logger.Errorf("failed to export events: %v", err)
+	}
+}
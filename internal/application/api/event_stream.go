@@ -0,0 +1,191 @@
+package api
+
+import (
+	"main/internal/domain/events"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamHeartbeatInterval = 15 * time.Second
+	websocketWriteTimeout   = 10 * time.Second
+	// streamRetryAfter is suggested to clients of a follower, a new leader is usually elected within it
+	streamRetryAfter = 5 * time.Second
+)
+
+// newWebsocketUpgrader only accepts browser connections from the origin serving the API or from
+// the comma separated allowed origins, e.g. "https://dashboard.example.com". CORS doesn't apply
+// to WebSocket upgrades, without this check any site could read the stream with the user's session.
+func newWebsocketUpgrader(allowedOrigins string) websocket.Upgrader {
+	allowed := make(map[string]struct{})
+	for _, origin := range strings.Split(allowedOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = struct{}{}
+		}
+	}
+
+	return websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			// Clients other than browsers don't send an origin
+			if origin == "" {
+				return true
+			}
+			if _, ok := allowed["*"]; ok {
+				return true
+			}
+			if _, ok := allowed[strings.ToLower(origin)]; ok {
+				return true
+			}
+			parsed, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(parsed.Host, r.Host)
+		},
+	}
+}
+
+// StreamEvents pushes events to the client as soon as the watchers receive them.
+// Server-Sent Events are used by default, WebSocket is used when the client requests an upgrade.
+// Only the leader replica watches the cluster, so followers answer 503 with the identity of the
+// leader and open streams are closed when the leadership is lost. Clients should reconnect,
+// load balancers may use the 503 to route the stream to the leader.
+func (c *EventController) StreamEvents(ctx *gin.Context) {
+	if !c.leader.IsLeader() {
+		ctx.Header("Retry-After", strconv.Itoa(int(streamRetryAfter.Seconds())))
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":  "live events are only streamed by the leader replica",
+			"leader": c.leader.Status().Leader,
+		})
+		return
+	}
+
+	filter := events.SubscriptionFilter{
+		Type: ctx.Query("type"),
+	}
+	for _, value := range ctx.QueryArray("namespace") {
+		for _, namespace := range strings.Split(value, ",") {
+			if namespace = strings.TrimSpace(namespace); namespace != "" {
+				filter.Namespaces = append(filter.Namespaces, namespace)
+			}
+		}
+	}
+
+	if websocket.IsWebSocketUpgrade(ctx.Request) {
+		c.streamEventsWebSocket(ctx, filter)
+		return
+	}
+	c.streamEventsSSE(ctx, filter)
+}
+
+func (c *EventController) streamEventsSSE(ctx *gin.Context, filter events.SubscriptionFilter) {
+	subscription := c.eventService.Subscribe(filter)
+	defer c.eventService.Unsubscribe(subscription)
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	var reportedDrops int64
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				c.logger.Warnf("event stream subscriber was disconnected after dropping %d events: %v", subscription.Dropped(), subscription.Err())
+				ctx.SSEvent("closed", gin.H{"error": subscription.Err().Error()})
+				ctx.Writer.Flush()
+				return
+			}
+			ctx.SSEvent("event", event)
+			ctx.Writer.Flush()
+		case <-heartbeat.C:
+			if dropped := subscription.Dropped(); dropped != reportedDrops {
+				reportedDrops = dropped
+				ctx.SSEvent("dropped", gin.H{"dropped": dropped})
+			} else {
+				if _, err := ctx.Writer.WriteString(": heartbeat\n\n"); err != nil {
+					return
+				}
+			}
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+func (c *EventController) streamEventsWebSocket(ctx *gin.Context, filter events.SubscriptionFilter) {
+	conn, err := c.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		c.logger.Errorf("failed to upgrade event stream to websocket: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	subscription := c.eventService.Subscribe(filter)
+	defer c.eventService.Unsubscribe(subscription)
+
+	// The client isn't expected to send anything, reading is only needed to process
+	// control frames and to notice when the connection is closed
+	closed := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeatInterval))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeatInterval))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	var reportedDrops int64
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				c.logger.Warnf("event stream subscriber was disconnected after dropping %d events: %v", subscription.Dropped(), subscription.Err())
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, subscription.Err().Error()),
+					time.Now().Add(websocketWriteTimeout))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+			if err := conn.WriteJSON(gin.H{"type": "event", "event": event}); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if dropped := subscription.Dropped(); dropped != reportedDrops {
+				reportedDrops = dropped
+				conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+				if err := conn.WriteJSON(gin.H{"type": "dropped", "dropped": dropped}); err != nil {
+					return
+				}
+			}
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package api
 
 import (
+	"errors"
+	"main/internal/domain/metrics"
 	"main/internal/infrastructure/kubernetes"
+	"main/pkg"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,10 +12,11 @@ import (
 
 type NamespaceController struct {
 	kubernetesClient *kubernetes.KubernetesClient
+	logger           pkg.Logger
 }
 
-func NewNamespaceController(kubernetesClient *kubernetes.KubernetesClient) *NamespaceController {
-	return &NamespaceController{kubernetesClient: kubernetesClient}
+func NewNamespaceController(logger pkg.Logger, kubernetesClient *kubernetes.KubernetesClient) *NamespaceController {
+	return &NamespaceController{kubernetesClient: kubernetesClient, logger: logger}
 }
 
 func (c *NamespaceController) GetNamespaces(ctx *gin.Context) {
@@ -24,3 +28,21 @@ func (c *NamespaceController) GetNamespaces(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, namespaces)
 }
+
+// GetNamespaceSummary returns the resource usage of the namespace against its quotas
+func (c *NamespaceController) GetNamespaceSummary(ctx *gin.Context) {
+	namespace := ctx.Param("namespace")
+
+	summary, err := c.kubernetesClient.GetNamespaceSummary(ctx.Request.Context(), namespace)
+	if errors.Is(err, metrics.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get namespace summary"})
+		c.logger.Errorf("Failed to get summary of namespace %s: %v", namespace, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, summary)
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"errors"
+	"main/internal/domain/events"
+	"main/internal/domain/metrics"
 	"main/internal/infrastructure/kubernetes"
 	"main/pkg"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,15 +15,16 @@ import (
 
 type NodeController struct {
 	kubernetesClient *kubernetes.KubernetesClient
+	eventService     *events.EventService
 	logger           pkg.Logger
 }
 
-func NewNodeController(kubernetesClient *kubernetes.KubernetesClient) *NodeController {
-	return &NodeController{kubernetesClient: kubernetesClient}
+func NewNodeController(logger pkg.Logger, kubernetesClient *kubernetes.KubernetesClient, eventService *events.EventService) *NodeController {
+	return &NodeController{kubernetesClient: kubernetesClient, eventService: eventService, logger: logger}
 }
 
 func (c *NodeController) GetNodes(ctx *gin.Context) {
-	nodes, err := c.kubernetesClient.GetNodes()
+	nodes, err := c.kubernetesClient.GetNodes(ctx.Request.Context())
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		c.logger.Errorf("Failed to get nodes: %v", err)
@@ -29,6 +34,36 @@ func (c *NodeController) GetNodes(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, nodes)
 }
 
+// GetNodeAllocation reports the requests and limits of every node against its allocatable
+func (c *NodeController) GetNodeAllocation(ctx *gin.Context) {
+	allocation, err := c.kubernetesClient.GetNodeAllocation(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.logger.Errorf("Failed to get node allocation: %v", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, allocation)
+}
+
+// GetNode returns the conditions, taints, resources and pods of the node
+func (c *NodeController) GetNode(ctx *gin.Context) {
+	nodeName := ctx.Param("node")
+
+	node, err := c.kubernetesClient.GetNode(ctx.Request.Context(), nodeName)
+	if errors.Is(err, metrics.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get node"})
+		c.logger.Errorf("Failed to get node %s: %v", nodeName, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, node)
+}
+
 func (c *NodeController) GetNodeMetrics(ctx *gin.Context) {
 	nodeName := ctx.Param("node")
 
@@ -57,12 +92,44 @@ func (c *NodeController) GetNodeMetrics(ctx *gin.Context) {
 		return
 	}
 
-	metrics, err := c.kubernetesClient.GetNodeHistoricalMetrics(nodeName, start, end, step)
+	history, err := c.kubernetesClient.GetNodeHistoricalMetrics(ctx.Request.Context(), nodeName, parseMetricNames(ctx), start, end, step)
+	if errors.Is(err, metrics.ErrUnknownMetric) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, metrics.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		c.logger.Errorf("Failed to get node metrics: %v", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, metrics)
+	ctx.JSON(http.StatusOK, history)
+}
+
+// GetNodeEvents describes the node together with its stored and live events
+func (c *NodeController) GetNodeEvents(ctx *gin.Context) {
+	nodeName := ctx.Param("node")
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+
+	description, err := c.eventService.DescribeNode(ctx.Request.Context(), nodeName, limit)
+	if errors.Is(err, events.ErrObjectNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to describe node"})
+		c.logger.Errorf("Failed to describe node %s: %v", nodeName, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, description)
 }
@@ -1,9 +1,14 @@
 package api
 
 import (
+	"errors"
+	"main/internal/domain/events"
+	"main/internal/domain/metrics"
 	"main/internal/infrastructure/kubernetes"
 	"main/pkg"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,17 +16,17 @@ import (
 
 type PodController struct {
 	kubernetesClient *kubernetes.KubernetesClient
+	eventService     *events.EventService
 	logger           pkg.Logger
 }
 
-func NewPodController(kubernetesClient *kubernetes.KubernetesClient) *PodController {
-	return &PodController{kubernetesClient: kubernetesClient}
+func NewPodController(logger pkg.Logger, kubernetesClient *kubernetes.KubernetesClient, eventService *events.EventService) *PodController {
+	return &PodController{kubernetesClient: kubernetesClient, eventService: eventService, logger: logger}
 }
 
 func (c *PodController) GetPods(ctx *gin.Context) {
 	namespace := ctx.DefaultQuery("namespace", "default")
-	println(namespace)
-	pods, err := c.kubernetesClient.GetPods(namespace)
+	pods, err := c.kubernetesClient.GetPods(ctx.Request.Context(), namespace)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		c.logger.Errorf("Failed to get pods: %v", err)
@@ -60,12 +65,52 @@ func (c *PodController) GetPodMetrics(ctx *gin.Context) {
 		return
 	}
 
-	metrics, err := c.kubernetesClient.GetPodHistoricalMetrics(namespace, podName, start, end, step)
+	history, err := c.kubernetesClient.GetPodHistoricalMetrics(ctx.Request.Context(), namespace, podName, parseMetricNames(ctx), start, end, step)
+	if errors.Is(err, metrics.ErrUnknownMetric) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		c.logger.Errorf("Failed to get pod metrics: %v", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, metrics)
+	ctx.JSON(http.StatusOK, history)
+}
+
+// parseMetricNames reads the comma separated metrics parameter, e.g. metrics=cpu_usage,load1
+func parseMetricNames(ctx *gin.Context) []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(ctx.Query("metrics"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// GetPodEvents describes the pod together with its stored and live events
+func (c *PodController) GetPodEvents(ctx *gin.Context) {
+	namespace := ctx.Param("namespace")
+	podName := ctx.Param("pod")
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+
+	description, err := c.eventService.DescribePod(ctx.Request.Context(), namespace, podName, limit)
+	if errors.Is(err, events.ErrObjectNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to describe pod"})
+		c.logger.Errorf("Failed to describe pod %s/%s: %v", namespace, podName, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, description)
 }
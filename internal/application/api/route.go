@@ -3,35 +3,53 @@ package api
 import (
 	"main/pkg/handler"
 
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/fx"
 )
 
-func SetupRoutes(handler handler.RequestHandler, nodeController *NodeController, namespaceController *NamespaceController, podController *PodController, eventController *EventController, telegramAlertController *TelegramAlertController) {
+func SetupRoutes(handler handler.RequestHandler, nodeController *NodeController, namespaceController *NamespaceController, podController *PodController, eventController *EventController, telegramAlertController *TelegramAlertController, statusController *StatusController, anomalyController *AnomalyController, workloadController *WorkloadController) {
 	nodeGroup := handler.Group("/api/nodes")
 	{
 		nodeGroup.GET("", nodeController.GetNodes)
 		nodeGroup.GET("/metrics/:node", nodeController.GetNodeMetrics)
+		nodeGroup.GET("/allocation", nodeController.GetNodeAllocation)
+		nodeGroup.GET("/:node", nodeController.GetNode)
+		nodeGroup.GET("/:node/events", nodeController.GetNodeEvents)
 	}
 
 	namespaceGroup := handler.Group("/api/namespaces")
 	{
 		namespaceGroup.GET("", namespaceController.GetNamespaces)
+		namespaceGroup.GET("/:namespace/summary", namespaceController.GetNamespaceSummary)
 	}
 
 	podGroup := handler.Group("/api/pods")
 	{
 		podGroup.GET("", podController.GetPods)
 		podGroup.GET("/metrics/:namespace/:pod", podController.GetPodMetrics)
+		podGroup.GET("/:namespace/:pod/events", podController.GetPodEvents)
+	}
+
+	workloadGroup := handler.Group("/api/workloads")
+	{
+		workloadGroup.GET("", workloadController.GetWorkloads)
+		workloadGroup.GET("/:namespace/:kind/:name", workloadController.GetWorkload)
 	}
 
 	eventsGroup := handler.Group("/api/events")
 	{
 		eventsGroup.GET("", eventController.ListEvents)
+		eventsGroup.GET("/stats", eventController.GetEventStats)
+		eventsGroup.GET("/stream", eventController.StreamEvents)
+		eventsGroup.GET("/export", eventController.ExportEvents)
+		eventsGroup.GET("/anomalies", anomalyController.GetAnomalies)
 	}
 
 	watchedNamespacesGroup := handler.Group("/api/watched_namespaces")
 	{
 		watchedNamespacesGroup.GET("", eventController.GetWatchedNamespaces)
+		watchedNamespacesGroup.GET("/status", eventController.GetWatchedNamespacesStatus)
 		watchedNamespacesGroup.POST("", eventController.AddWatchedNamespace)
 		watchedNamespacesGroup.DELETE("/:namespace", eventController.RemoveWatchedNamespace)
 	}
@@ -39,10 +57,21 @@ func SetupRoutes(handler handler.RequestHandler, nodeController *NodeController,
 	alertsGroup := handler.Group("/api/alerts")
 	{
 		alertsGroup.POST("", telegramAlertController.CreateAlert)
+		alertsGroup.POST("/replay", telegramAlertController.ReplayAlerts)
 		alertsGroup.GET("/namespace/:namespace", telegramAlertController.GetAlertsByNamespace)
 		alertsGroup.DELETE("/:id", telegramAlertController.DeleteAlert)
 		alertsGroup.PUT("/:id", telegramAlertController.UpdateAlert)
 	}
+
+	statusGroup := handler.Group("/api/status")
+	{
+		statusGroup.GET("", statusController.GetStatus)
+	}
+
+	metricsGroup := handler.Group("/metrics")
+	{
+		metricsGroup.GET("", gin.WrapH(promhttp.Handler()))
+	}
 }
 
 var Module = fx.Module("api",
@@ -52,4 +81,7 @@ var Module = fx.Module("api",
 	fx.Invoke(SetupRoutes),
 	fx.Provide(NewEventController),
 	fx.Provide(NewTelegramAlertController),
+	fx.Provide(NewStatusController),
+	fx.Provide(NewAnomalyController),
+	fx.Provide(NewWorkloadController),
 )
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"main/internal/domain/leadership"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatusController struct {
+	leader leadership.Elector
+}
+
+func NewStatusController(leader leadership.Elector) *StatusController {
+	return &StatusController{leader: leader}
+}
+
+func (c *StatusController) GetStatus(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"leadership": c.leader.Status(),
+	})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"main/internal/domain/alerts"
 	"main/pkg"
 	"net/http"
@@ -10,14 +11,16 @@ import (
 )
 
 type TelegramAlertController struct {
-	logger  pkg.Logger
-	service alerts.TelegramAlertService
+	logger        pkg.Logger
+	service       alerts.TelegramAlertService
+	replayService *alerts.AlertReplayService
 }
 
-func NewTelegramAlertController(logger pkg.Logger, service alerts.TelegramAlertService) *TelegramAlertController {
+func NewTelegramAlertController(logger pkg.Logger, service alerts.TelegramAlertService, replayService *alerts.AlertReplayService) *TelegramAlertController {
 	return &TelegramAlertController{
-		logger:  logger,
-		service: service,
+		logger:        logger,
+		service:       service,
+		replayService: replayService,
 	}
 }
 
@@ -177,3 +180,30 @@ func (c *TelegramAlertController) GetAllAlerts(ctx *gin.Context) {
 		"total":  len(responses),
 	})
 }
+
+// ReplayAlerts shows which messages the alerts of a namespace would have sent for the
+// stored events of a time range, nothing is sent
+func (c *TelegramAlertController) ReplayAlerts(ctx *gin.Context) {
+	request := alerts.ReplayRequest{Limit: 100}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if request.Limit < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	result, err := c.replayService.Replay(request)
+	if errors.Is(err, alerts.ErrInvalidReplay) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.logger.Errorf("failed to replay alerts: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to replay alerts"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"main/internal/domain/metrics"
+	"main/internal/infrastructure/kubernetes"
+	"main/pkg"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkloadController struct {
+	kubernetesClient *kubernetes.KubernetesClient
+	logger           pkg.Logger
+}
+
+func NewWorkloadController(logger pkg.Logger, kubernetesClient *kubernetes.KubernetesClient) *WorkloadController {
+	return &WorkloadController{kubernetesClient: kubernetesClient, logger: logger}
+}
+
+func (c *WorkloadController) GetWorkloads(ctx *gin.Context) {
+	namespace := ctx.DefaultQuery("namespace", "default")
+	workloads, err := c.kubernetesClient.GetWorkloads(ctx.Request.Context(), namespace)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.logger.Errorf("Failed to get workloads: %v", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workloads)
+}
+
+// GetWorkload returns the workload with its pods and, for deployments, the revision history
+func (c *WorkloadController) GetWorkload(ctx *gin.Context) {
+	namespace := ctx.Param("namespace")
+	kind := ctx.Param("kind")
+	name := ctx.Param("name")
+
+	workload, err := c.kubernetesClient.GetWorkload(ctx.Request.Context(), namespace, kind, name)
+	if errors.Is(err, metrics.ErrUnknownWorkloadKind) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, metrics.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get workload"})
+		c.logger.Errorf("Failed to get %s %s/%s: %v", kind, namespace, name, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workload)
+}
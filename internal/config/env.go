@@ -5,11 +5,15 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"time"
 
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
 )
 
+// EventRateBucketSize is the resolution event occurrences are recorded and compared with
+const EventRateBucketSize = 5 * time.Minute
+
 type Env struct {
 	AppEnv        string `mapstructure:"APP_ENV"`
 	ServerAddress string `mapstructure:"SERVER_HOST"`
@@ -26,6 +30,28 @@ type Env struct {
 	RedisPass string `mapstructure:"REDIS_PASS"`
 
 	PrometheusHost string `mapstructure:"PROMETHEUS_HOST"`
+	// MetricsSource is prometheus, metrics-server or auto
+	MetricsSource string `mapstructure:"METRICS_SOURCE"`
+	// PrometheusQueryPreset is kube-prometheus-stack or plain, PrometheusQueryFile may extend or replace it
+	PrometheusQueryPreset string `mapstructure:"PROMETHEUS_QUERY_PRESET"`
+	PrometheusQueryFile   string `mapstructure:"PROMETHEUS_QUERY_FILE"`
+
+	LeaderElection          string `mapstructure:"LEADER_ELECTION"`
+	LeaderElectionNamespace string `mapstructure:"LEADER_ELECTION_NAMESPACE"`
+	LeaderElectionLease     string `mapstructure:"LEADER_ELECTION_LEASE"`
+	PodName                 string `mapstructure:"POD_NAME"`
+
+	PodPendingThreshold string `mapstructure:"POD_PENDING_THRESHOLD"`
+
+	AutoWatchNamespaceSelector string `mapstructure:"AUTO_WATCH_NAMESPACE_SELECTOR"`
+
+	AnomalyAlerts         string `mapstructure:"ANOMALY_ALERTS"`
+	AnomalyWindow         string `mapstructure:"ANOMALY_WINDOW"`
+	AnomalyThreshold      string `mapstructure:"ANOMALY_THRESHOLD"`
+	AnomalyMinOccurrences string `mapstructure:"ANOMALY_MIN_OCCURRENCES"`
+
+	// WebsocketAllowedOrigins lists the origins besides the API's own that may open the event stream
+	WebsocketAllowedOrigins string `mapstructure:"WEBSOCKET_ALLOWED_ORIGINS"`
 
 	AuthKey   string `mapstructure:"AUTH_KEY"`
 	PublicKey string
@@ -39,10 +65,28 @@ func NewEnv() Env {
 	_, err := os.Stat(".env")
 	useEnvFile := !os.IsNotExist(err)
 
-	viper.SetDefault("REDIS_HOST", "localhost")
+	// Prometheus results are cached in memory unless REDIS_HOST is set
+	viper.SetDefault("REDIS_HOST", "")
 	viper.SetDefault("REDIS_PORT", "6379")
 	viper.SetDefault("REDIS_PASS", "")
 
+	viper.SetDefault("METRICS_SOURCE", "auto")
+	viper.SetDefault("PROMETHEUS_QUERY_PRESET", "kube-prometheus-stack")
+	viper.SetDefault("PROMETHEUS_QUERY_FILE", "")
+
+	viper.SetDefault("LEADER_ELECTION", "true")
+	viper.SetDefault("LEADER_ELECTION_NAMESPACE", "default")
+	viper.SetDefault("LEADER_ELECTION_LEASE", "diplom-backend-leader")
+
+	viper.SetDefault("POD_PENDING_THRESHOLD", "5m")
+
+	viper.SetDefault("WEBSOCKET_ALLOWED_ORIGINS", "")
+
+	viper.SetDefault("ANOMALY_ALERTS", "false")
+	viper.SetDefault("ANOMALY_WINDOW", "15m")
+	viper.SetDefault("ANOMALY_THRESHOLD", "3")
+	viper.SetDefault("ANOMALY_MIN_OCCURRENCES", "10")
+
 	if useEnvFile {
 		viper.SetConfigType("env")
 		viper.SetConfigName(".env")
@@ -0,0 +1,154 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"main/internal/domain/events"
+	"strings"
+	"time"
+)
+
+var ErrInvalidReplay = errors.New("invalid replay request")
+
+// Matches reports whether the event is delivered to the chat of this alert
+func (a TelegramAlert) Matches(event events.Event) bool {
+	if a.Namespace != event.Namespace {
+		return false
+	}
+	switch a.AlertType {
+	case AlertTypeAll:
+		return true
+	case AlertTypeNormal:
+		return strings.EqualFold(event.Type, "Normal")
+	case AlertTypeWarning:
+		return strings.EqualFold(event.Type, "Warning")
+	}
+	return false
+}
+
+// EventMessage renders the telegram message sent for an event
+func EventMessage(event events.Event) string {
+	message := fmt.Sprintf("[%s] %s in namespace %s\nObject: %s\n%s",
+		event.Type, event.Reason, event.Namespace, event.InvolvedObject, event.Message)
+	if event.Count > 1 {
+		message += fmt.Sprintf("\nSeen %d times, last at %s", event.Count, event.LastTimestamp.Format(time.RFC3339))
+	}
+	return message
+}
+
+type ReplayRequest struct {
+	Namespace string    `json:"namespace"`
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+	// Limit caps the messages returned per chat, the totals are always complete
+	Limit int `json:"limit"`
+}
+
+type ReplayMessage struct {
+	EventID       string    `json:"event_id"`
+	Reason        string    `json:"reason"`
+	Type          string    `json:"type"`
+	LastTimestamp time.Time `json:"last_timestamp"`
+	Text          string    `json:"text"`
+}
+
+type ReplayChat struct {
+	AlertID   int64           `json:"alert_id"`
+	ChatID    string          `json:"chat_id"`
+	ThreadID  *int            `json:"thread_id,omitempty"`
+	AlertType AlertType       `json:"alert_type"`
+	Total     int             `json:"total"`
+	Messages  []ReplayMessage `json:"messages"`
+}
+
+type ReplayResult struct {
+	Namespace     string       `json:"namespace"`
+	Since         time.Time    `json:"since"`
+	Until         time.Time    `json:"until"`
+	EventsScanned int          `json:"events_scanned"`
+	EventsMatched int          `json:"events_matched"`
+	Chats         []ReplayChat `json:"chats"`
+}
+
+// AlertReplayService runs stored events through the configured alerts without sending anything
+type AlertReplayService struct {
+	repository   TelegramAlertRepository
+	eventService *events.EventService
+}
+
+func NewAlertReplayService(repository TelegramAlertRepository, eventService *events.EventService) *AlertReplayService {
+	return &AlertReplayService{
+		repository:   repository,
+		eventService: eventService,
+	}
+}
+
+// Replay returns the messages every chat of the namespace would have received for the
+// events of the time range, in the order they would have been sent
+func (s *AlertReplayService) Replay(request ReplayRequest) (*ReplayResult, error) {
+	if request.Namespace == "" {
+		return nil, fmt.Errorf("%w: namespace is required", ErrInvalidReplay)
+	}
+	if request.Since.IsZero() || request.Until.IsZero() || !request.Until.After(request.Since) {
+		return nil, fmt.Errorf("%w: since must be before until", ErrInvalidReplay)
+	}
+
+	configured, err := s.repository.GetAlertsByNamespace(request.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReplayResult{
+		Namespace: request.Namespace,
+		Since:     request.Since,
+		Until:     request.Until,
+		Chats:     make([]ReplayChat, 0, len(configured)),
+	}
+	for _, alert := range configured {
+		result.Chats = append(result.Chats, ReplayChat{
+			AlertID:   alert.ID,
+			ChatID:    alert.ChatID,
+			ThreadID:  alert.ThreadID,
+			AlertType: alert.AlertType,
+			Messages:  make([]ReplayMessage, 0),
+		})
+	}
+
+	filter := events.EventFilter{
+		Namespaces: []string{request.Namespace},
+		Since:      &request.Since,
+		Until:      &request.Until,
+		Order:      events.SortOrderAsc,
+	}
+	err = s.eventService.ExportEvents(filter, func(event events.Event) error {
+		result.EventsScanned++
+		matched := false
+		for i, alert := range configured {
+			if !alert.Matches(event) {
+				continue
+			}
+			matched = true
+			chat := &result.Chats[i]
+			chat.Total++
+			if request.Limit > 0 && len(chat.Messages) >= request.Limit {
+				continue
+			}
+			chat.Messages = append(chat.Messages, ReplayMessage{
+				EventID:       event.ID,
+				Reason:        event.Reason,
+				Type:          event.Type,
+				LastTimestamp: event.LastTimestamp,
+				Text:          EventMessage(event),
+			})
+		}
+		if matched {
+			result.EventsMatched++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
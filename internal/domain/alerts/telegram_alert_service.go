@@ -12,6 +12,7 @@ import (
 
 var Module = fx.Module("alerts",
 	fx.Provide(NewTelegramAlertService),
+	fx.Provide(NewAlertReplayService),
 )
 
 type telegramAlertService struct {
@@ -0,0 +1,158 @@
+package anomalies
+
+import (
+	"context"
+	"fmt"
+	"main/internal/config"
+	"main/internal/domain/alerts"
+	"main/internal/domain/leadership"
+	"main/pkg"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// alertCooldown is how long an anomaly that is still ongoing isn't reported again
+const alertCooldown = time.Hour
+
+// AnomalyAlerter periodically runs the detection on the leader and sends the anomalies
+// to the telegram alerts of their namespace, it's only started when ANOMALY_ALERTS is enabled
+type AnomalyAlerter struct {
+	logger       pkg.Logger
+	service      *AnomalyService
+	alertService alerts.TelegramAlertService
+
+	mu          sync.Mutex
+	running     bool
+	leaderCtx   context.Context
+	cancel      context.CancelFunc
+	loop        sync.WaitGroup
+	lastAlerted map[[2]string]time.Time
+}
+
+func NewAnomalyAlerter(lc fx.Lifecycle, env config.Env, logger pkg.Logger, service *AnomalyService, alertService alerts.TelegramAlertService, leader leadership.Elector) {
+	if env.AnomalyAlerts != "true" {
+		return
+	}
+
+	alerter := &AnomalyAlerter{
+		logger:       logger,
+		service:      service,
+		alertService: alertService,
+		lastAlerted:  make(map[[2]string]time.Time),
+	}
+	leader.OnStartedLeading(alerter.startLeading)
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			alerter.mu.Lock()
+			defer alerter.mu.Unlock()
+			alerter.running = true
+			alerter.run()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			alerter.mu.Lock()
+			alerter.running = false
+			if alerter.cancel != nil {
+				alerter.cancel()
+			}
+			alerter.mu.Unlock()
+			alerter.loop.Wait()
+			return nil
+		},
+	})
+}
+
+func (a *AnomalyAlerter) startLeading(ctx context.Context) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.leaderCtx = ctx
+	a.run()
+}
+
+// run starts the loop for the current leadership, a.mu must be held
+func (a *AnomalyAlerter) run() {
+	if !a.running || a.leaderCtx == nil || a.leaderCtx.Err() != nil {
+		return
+	}
+	// The previous loop is stopped first, lastAlerted is only used by one loop at a time
+	if a.cancel != nil {
+		a.cancel()
+		a.loop.Wait()
+	}
+
+	ctx, cancel := context.WithCancel(a.leaderCtx)
+	a.cancel = cancel
+	a.loop.Add(1)
+	go func() {
+		defer a.loop.Done()
+		ticker := time.NewTicker(RateBucketSize)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				a.check(time.Now())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (a *AnomalyAlerter) check(now time.Time) {
+	anomalies, err := a.service.Detect(a.service.Defaults())
+	if err != nil {
+		a.logger.Errorf("Failed to detect event anomalies: %v", err)
+		return
+	}
+
+	for _, anomaly := range a.due(anomalies, now) {
+		a.send(anomaly)
+	}
+}
+
+// due returns the anomalies that weren't reported within the cooldown and records them as reported
+func (a *AnomalyAlerter) due(anomalies []Anomaly, now time.Time) []Anomaly {
+	result := make([]Anomaly, 0, len(anomalies))
+	for _, anomaly := range anomalies {
+		key := [2]string{anomaly.Namespace, anomaly.Reason}
+		if alerted, ok := a.lastAlerted[key]; ok && now.Sub(alerted) < alertCooldown {
+			continue
+		}
+		a.lastAlerted[key] = now
+		result = append(result, anomaly)
+	}
+
+	for key, alerted := range a.lastAlerted {
+		if now.Sub(alerted) >= alertCooldown {
+			delete(a.lastAlerted, key)
+		}
+	}
+	return result
+}
+
+func (a *AnomalyAlerter) send(anomaly Anomaly) {
+	subscriptions, err := a.alertService.GetAlertsByNamespace(anomaly.Namespace)
+	if err != nil {
+		a.logger.Errorf("Failed to get alerts for namespace %s: %v", anomaly.Namespace, err)
+		return
+	}
+
+	message := fmt.Sprintf(
+		"[%s] Event rate anomaly in namespace %s\nReason: %s\n%d occurrences between %s and %s, baseline %.1f ± %.1f (z-score %.1f, %s)",
+		anomaly.Severity, anomaly.Namespace, anomaly.Reason, anomaly.Occurrences,
+		anomaly.WindowStart.Format(time.RFC3339), anomaly.WindowEnd.Format(time.RFC3339),
+		anomaly.BaselineMean, anomaly.BaselineStdDev, anomaly.ZScore, anomaly.Baseline,
+	)
+	for _, subscription := range subscriptions {
+		// Anomalies are reported like warning events
+		if subscription.AlertType != alerts.AlertTypeAll && subscription.AlertType != alerts.AlertTypeWarning {
+			continue
+		}
+		if err := a.alertService.SendAlert(subscription, message); err != nil {
+			a.logger.Errorf("Failed to send anomaly alert %d: %v", subscription.ID, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package anomalies
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlerterCooldown(t *testing.T) {
+	alerter := &AnomalyAlerter{lastAlerted: make(map[[2]string]time.Time)}
+	backOff := Anomaly{Namespace: "default", Reason: "BackOff"}
+	failed := Anomaly{Namespace: "default", Reason: "Failed"}
+	otherNamespace := Anomaly{Namespace: "kube-system", Reason: "BackOff"}
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name      string
+		at        time.Duration
+		anomalies []Anomaly
+		expected  []Anomaly
+	}{
+		{name: "first occurrence is reported", at: 0, anomalies: []Anomaly{backOff}, expected: []Anomaly{backOff}},
+		{name: "ongoing anomaly is muted", at: 5 * time.Minute, anomalies: []Anomaly{backOff}, expected: []Anomaly{}},
+		{name: "other reason is reported", at: 10 * time.Minute, anomalies: []Anomaly{backOff, failed}, expected: []Anomaly{failed}},
+		{name: "same reason in another namespace is reported", at: 15 * time.Minute, anomalies: []Anomaly{otherNamespace}, expected: []Anomaly{otherNamespace}},
+		{name: "still muted just before the cooldown ends", at: alertCooldown - time.Second, anomalies: []Anomaly{backOff}, expected: []Anomaly{}},
+		{name: "reported again after the cooldown", at: alertCooldown, anomalies: []Anomaly{backOff, failed}, expected: []Anomaly{backOff}},
+	}
+
+	for _, step := range steps {
+		due := alerter.due(step.anomalies, start.Add(step.at))
+		if len(due) != len(step.expected) {
+			t.Fatalf("%s: got %v, want %v", step.name, due, step.expected)
+		}
+		for i := range due {
+			if due[i] != step.expected[i] {
+				t.Errorf("%s: got %v, want %v", step.name, due, step.expected)
+			}
+		}
+	}
+}
+
+func TestAlerterForgetsExpiredAlerts(t *testing.T) {
+	alerter := &AnomalyAlerter{lastAlerted: make(map[[2]string]time.Time)}
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	alerter.due([]Anomaly{{Namespace: "default", Reason: "BackOff"}}, start)
+	alerter.due(nil, start.Add(alertCooldown))
+	if len(alerter.lastAlerted) != 0 {
+		t.Errorf("lastAlerted = %v, want expired alerts removed", alerter.lastAlerted)
+	}
+}
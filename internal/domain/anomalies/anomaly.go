@@ -0,0 +1,79 @@
+package anomalies
+
+import (
+	"errors"
+	"main/internal/config"
+	"time"
+)
+
+// RateBucketSize is the resolution event occurrences are recorded with
+const RateBucketSize = config.EventRateBucketSize
+
+var ErrInvalidWindow = errors.New("window must be a multiple of 5m that divides an hour")
+
+type Severity string
+
+const (
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+type BaselineKind string
+
+const (
+	// BaselineSeasonal compares with the same time of day on the previous days
+	BaselineSeasonal BaselineKind = "seasonal"
+	// BaselineRolling compares with the preceding windows while there isn't enough history yet
+	BaselineRolling BaselineKind = "rolling"
+)
+
+// Anomaly is a spike of occurrences of one event reason in a namespace
+type Anomaly struct {
+	Namespace      string       `json:"namespace"`
+	Reason         string       `json:"reason"`
+	WindowStart    time.Time    `json:"window_start"`
+	WindowEnd      time.Time    `json:"window_end"`
+	Occurrences    int64        `json:"occurrences"`
+	BaselineMean   float64      `json:"baseline_mean"`
+	BaselineStdDev float64      `json:"baseline_stddev"`
+	ZScore         float64      `json:"z_score"`
+	Baseline       BaselineKind `json:"baseline"`
+	Samples        int          `json:"samples"`
+	Severity       Severity     `json:"severity"`
+}
+
+// DetectionOptions tunes a single detection run, At defaults to now
+type DetectionOptions struct {
+	Namespaces     []string
+	Window         time.Duration
+	Threshold      float64
+	MinOccurrences int64
+	At             time.Time
+}
+
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// RateQuery sums occurrences into windows aligned to Origin over the given ranges
+type RateQuery struct {
+	Namespaces []string
+	Ranges     []TimeRange
+	Window     time.Duration
+	Origin     time.Time
+}
+
+// RateAggregate holds the sum and the sum of squares of the per-window occurrences of a key,
+// windows without occurrences are not included
+type RateAggregate struct {
+	Namespace    string  `db:"namespace"`
+	Reason       string  `db:"reason"`
+	Total        float64 `db:"total"`
+	TotalSquares float64 `db:"total_squares"`
+}
+
+type EventRateRepository interface {
+	GetRateAggregates(query RateQuery) ([]RateAggregate, error)
+	GetFirstBucket() (*time.Time, error)
+}
@@ -0,0 +1,204 @@
+package anomalies
+
+import (
+	"main/internal/config"
+	"main/pkg"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+const (
+	// The seasonal baseline is used once there is enough history to cover a few days
+	seasonalMinHistory = 3 * 24 * time.Hour
+	seasonalDays       = 7
+	// seasonalSpread widens the same time of day on previous days to get more samples
+	seasonalSpread = time.Hour
+	rollingHistory = 24 * time.Hour
+	// minSamples is the smallest number of baseline windows a spike is judged against
+	minSamples = 12
+)
+
+var Module = fx.Module("anomalies",
+	fx.Provide(NewAnomalyService),
+	fx.Invoke(NewAnomalyAlerter),
+)
+
+// AnomalyService flags event reasons whose occurrences in the latest window are far above
+// their baseline. The baseline is the same time of day on the previous days, or the preceding
+// day while the history is shorter than that.
+type AnomalyService struct {
+	logger     pkg.Logger
+	repository EventRateRepository
+	defaults   DetectionOptions
+}
+
+func NewAnomalyService(env config.Env, logger pkg.Logger, repository EventRateRepository) *AnomalyService {
+	defaults := DetectionOptions{
+		Window:         15 * time.Minute,
+		Threshold:      3,
+		MinOccurrences: 10,
+	}
+	if window, err := time.ParseDuration(env.AnomalyWindow); err == nil && validWindow(window) {
+		defaults.Window = window
+	} else {
+		logger.Errorf("Invalid ANOMALY_WINDOW %q, using %s", env.AnomalyWindow, defaults.Window)
+	}
+	if threshold, err := strconv.ParseFloat(env.AnomalyThreshold, 64); err == nil && threshold > 0 {
+		defaults.Threshold = threshold
+	} else {
+		logger.Errorf("Invalid ANOMALY_THRESHOLD %q, using %.1f", env.AnomalyThreshold, defaults.Threshold)
+	}
+	if minOccurrences, err := strconv.ParseInt(env.AnomalyMinOccurrences, 10, 64); err == nil && minOccurrences > 0 {
+		defaults.MinOccurrences = minOccurrences
+	} else {
+		logger.Errorf("Invalid ANOMALY_MIN_OCCURRENCES %q, using %d", env.AnomalyMinOccurrences, defaults.MinOccurrences)
+	}
+
+	return &AnomalyService{
+		logger:     logger,
+		repository: repository,
+		defaults:   defaults,
+	}
+}
+
+// Defaults returns the configured detection options
+func (s *AnomalyService) Defaults() DetectionOptions {
+	return s.defaults
+}
+
+func (s *AnomalyService) Detect(options DetectionOptions) ([]Anomaly, error) {
+	if !validWindow(options.Window) {
+		return nil, ErrInvalidWindow
+	}
+	at := options.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	// The current window ends with the bucket that is still being filled
+	windowEnd := at.UTC().Truncate(RateBucketSize).Add(RateBucketSize)
+	windowStart := windowEnd.Add(-options.Window)
+	result := make([]Anomaly, 0)
+
+	first, err := s.repository.GetFirstBucket()
+	if err != nil || first == nil {
+		return result, err
+	}
+
+	current, err := s.repository.GetRateAggregates(RateQuery{
+		Namespaces: options.Namespaces,
+		Ranges:     []TimeRange{{Since: windowStart, Until: windowEnd}},
+		Window:     options.Window,
+		Origin:     windowStart,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]RateAggregate, 0)
+	namespaces := make([]string, 0)
+	seenNamespaces := make(map[string]struct{})
+	for _, aggregate := range current {
+		if int64(aggregate.Total) < options.MinOccurrences {
+			continue
+		}
+		candidates = append(candidates, aggregate)
+		if _, seen := seenNamespaces[aggregate.Namespace]; !seen {
+			seenNamespaces[aggregate.Namespace] = struct{}{}
+			namespaces = append(namespaces, aggregate.Namespace)
+		}
+	}
+	if len(candidates) == 0 {
+		return result, nil
+	}
+
+	ranges, samples, kind := baselineRanges(first.UTC(), windowStart, windowEnd, options.Window)
+	if samples < minSamples {
+		return result, nil
+	}
+
+	baseline, err := s.repository.GetRateAggregates(RateQuery{
+		Namespaces: namespaces,
+		Ranges:     ranges,
+		Window:     options.Window,
+		Origin:     windowStart,
+	})
+	if err != nil {
+		return nil, err
+	}
+	baselineByKey := make(map[[2]string]RateAggregate, len(baseline))
+	for _, aggregate := range baseline {
+		baselineByKey[[2]string{aggregate.Namespace, aggregate.Reason}] = aggregate
+	}
+
+	for _, candidate := range candidates {
+		history := baselineByKey[[2]string{candidate.Namespace, candidate.Reason}]
+		mean := history.Total / float64(samples)
+		stdDev := math.Sqrt(math.Max(history.TotalSquares/float64(samples)-mean*mean, 0))
+		// Rare reasons have a near zero deviation, the Poisson noise of the mean is the floor
+		sigma := math.Max(stdDev, math.Max(math.Sqrt(mean), 1))
+		zScore := (candidate.Total - mean) / sigma
+		if zScore < options.Threshold {
+			continue
+		}
+
+		severity := SeverityWarning
+		if zScore >= 2*options.Threshold {
+			severity = SeverityCritical
+		}
+		result = append(result, Anomaly{
+			Namespace:      candidate.Namespace,
+			Reason:         candidate.Reason,
+			WindowStart:    windowStart,
+			WindowEnd:      windowEnd,
+			Occurrences:    int64(candidate.Total),
+			BaselineMean:   mean,
+			BaselineStdDev: stdDev,
+			ZScore:         zScore,
+			Baseline:       kind,
+			Samples:        samples,
+			Severity:       severity,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ZScore > result[j].ZScore
+	})
+	return result, nil
+}
+
+// baselineRanges returns the time ranges the current window is compared with and the number
+// of windows they contain. Windows without any occurrences count as zero.
+func baselineRanges(first, windowStart, windowEnd time.Time, window time.Duration) ([]TimeRange, int, BaselineKind) {
+	history := windowStart.Sub(first)
+	if history >= seasonalMinHistory {
+		days := min(int(history/(24*time.Hour)), seasonalDays)
+		ranges := make([]TimeRange, 0, days)
+		for day := 1; day <= days; day++ {
+			offset := time.Duration(day) * 24 * time.Hour
+			ranges = append(ranges, TimeRange{
+				Since: windowStart.Add(-offset - seasonalSpread),
+				Until: windowEnd.Add(-offset + seasonalSpread),
+			})
+		}
+		windowsPerDay := int((2*seasonalSpread + window) / window)
+		return ranges, days * windowsPerDay, BaselineSeasonal
+	}
+
+	windows := int(min(history, rollingHistory) / window)
+	if windows <= 0 {
+		return nil, 0, BaselineRolling
+	}
+	return []TimeRange{{
+		Since: windowStart.Add(-time.Duration(windows) * window),
+		Until: windowStart,
+	}}, windows, BaselineRolling
+}
+
+func validWindow(window time.Duration) bool {
+	return window >= RateBucketSize && window <= time.Hour && window%RateBucketSize == 0 && time.Hour%window == 0
+}
@@ -0,0 +1,305 @@
+package anomalies
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type fakeRateRepository struct {
+	first    *time.Time
+	current  []RateAggregate
+	baseline []RateAggregate
+	queries  []RateQuery
+}
+
+// GetRateAggregates answers the first query of a detection with the current window
+// and every following one with the baseline
+func (r *fakeRateRepository) GetRateAggregates(query RateQuery) ([]RateAggregate, error) {
+	r.queries = append(r.queries, query)
+	if len(r.queries) == 1 {
+		return r.current, nil
+	}
+	return r.baseline, nil
+}
+
+func (r *fakeRateRepository) GetFirstBucket() (*time.Time, error) {
+	return r.first, nil
+}
+
+// baselineAggregate builds the aggregate of samples windows with the given mean and standard deviation
+func baselineAggregate(reason string, samples int, mean, stdDev float64) RateAggregate {
+	return RateAggregate{
+		Namespace:    "default",
+		Reason:       reason,
+		Total:        mean * float64(samples),
+		TotalSquares: (stdDev*stdDev + mean*mean) * float64(samples),
+	}
+}
+
+func TestDetect(t *testing.T) {
+	at := time.Date(2024, 3, 10, 12, 2, 0, 0, time.UTC)
+	windowStart := time.Date(2024, 3, 10, 11, 50, 0, 0, time.UTC)
+	firstBucket := func(history time.Duration) *time.Time {
+		first := windowStart.Add(-history)
+		return &first
+	}
+	current := func(occurrences float64) []RateAggregate {
+		return []RateAggregate{{Namespace: "default", Reason: "BackOff", Total: occurrences}}
+	}
+
+	tests := []struct {
+		name       string
+		repository *fakeRateRepository
+		expected   []Anomaly
+	}{
+		{
+			name:       "no stored buckets",
+			repository: &fakeRateRepository{current: current(100)},
+			expected:   []Anomaly{},
+		},
+		{
+			name: "zero variance uses the poisson noise of the mean",
+			repository: &fakeRateRepository{
+				first:    firstBucket(5 * 24 * time.Hour),
+				current:  current(20),
+				baseline: []RateAggregate{baselineAggregate("BackOff", 45, 4, 0)},
+			},
+			expected: []Anomaly{{
+				Occurrences: 20, BaselineMean: 4, BaselineStdDev: 0, ZScore: 8,
+				Baseline: BaselineSeasonal, Samples: 45, Severity: SeverityCritical,
+			}},
+		},
+		{
+			name: "reason without history is judged against a deviation of one",
+			repository: &fakeRateRepository{
+				first:   firstBucket(6 * time.Hour),
+				current: current(10),
+			},
+			expected: []Anomaly{{
+				Occurrences: 10, BaselineMean: 0, BaselineStdDev: 0, ZScore: 10,
+				Baseline: BaselineRolling, Samples: 24, Severity: SeverityCritical,
+			}},
+		},
+		{
+			name: "below the minimum occurrences",
+			repository: &fakeRateRepository{
+				first:   firstBucket(6 * time.Hour),
+				current: current(9),
+			},
+			expected: []Anomaly{},
+		},
+		{
+			name: "within the normal variation",
+			repository: &fakeRateRepository{
+				first:    firstBucket(6 * time.Hour),
+				current:  current(20),
+				baseline: []RateAggregate{baselineAggregate("BackOff", 24, 10, 5)},
+			},
+			expected: []Anomaly{},
+		},
+		{
+			name: "above the threshold is a warning",
+			repository: &fakeRateRepository{
+				first:    firstBucket(6 * time.Hour),
+				current:  current(30),
+				baseline: []RateAggregate{baselineAggregate("BackOff", 24, 10, 5)},
+			},
+			expected: []Anomaly{{
+				Occurrences: 30, BaselineMean: 10, BaselineStdDev: 5, ZScore: 4,
+				Baseline: BaselineRolling, Samples: 24, Severity: SeverityWarning,
+			}},
+		},
+		{
+			name: "not enough history for a baseline",
+			repository: &fakeRateRepository{
+				first:   firstBucket(time.Hour),
+				current: current(100),
+			},
+			expected: []Anomaly{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &AnomalyService{repository: test.repository}
+			anomalies, err := service.Detect(DetectionOptions{
+				Window:         15 * time.Minute,
+				Threshold:      3,
+				MinOccurrences: 10,
+				At:             at,
+			})
+			if err != nil {
+				t.Fatalf("failed to detect anomalies: %v", err)
+			}
+			if len(anomalies) != len(test.expected) {
+				t.Fatalf("got %d anomalies, want %d: %+v", len(anomalies), len(test.expected), anomalies)
+			}
+			for i, expected := range test.expected {
+				expected.Namespace, expected.Reason = "default", "BackOff"
+				expected.WindowStart, expected.WindowEnd = windowStart, windowStart.Add(15*time.Minute)
+				if !anomaliesEqual(anomalies[i], expected) {
+					t.Errorf("anomaly = %+v, want %+v", anomalies[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectSortsByZScore(t *testing.T) {
+	first := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	repository := &fakeRateRepository{
+		first: &first,
+		current: []RateAggregate{
+			{Namespace: "default", Reason: "BackOff", Total: 20},
+			{Namespace: "default", Reason: "Failed", Total: 40},
+		},
+	}
+	service := &AnomalyService{repository: repository}
+
+	anomalies, err := service.Detect(DetectionOptions{
+		Window:         15 * time.Minute,
+		Threshold:      3,
+		MinOccurrences: 10,
+		At:             time.Date(2024, 3, 10, 12, 2, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("failed to detect anomalies: %v", err)
+	}
+	if len(anomalies) != 2 || anomalies[0].Reason != "Failed" || anomalies[1].Reason != "BackOff" {
+		t.Errorf("anomalies = %+v, want Failed before BackOff", anomalies)
+	}
+	if baseline := repository.queries[1]; len(baseline.Namespaces) != 1 || baseline.Namespaces[0] != "default" {
+		t.Errorf("baseline namespaces = %q, want only the namespaces of the candidates", baseline.Namespaces)
+	}
+}
+
+func TestDetectRejectsInvalidWindow(t *testing.T) {
+	service := &AnomalyService{repository: &fakeRateRepository{}}
+	if _, err := service.Detect(DetectionOptions{Window: 7 * time.Minute}); err != ErrInvalidWindow {
+		t.Errorf("error = %v, want %v", err, ErrInvalidWindow)
+	}
+}
+
+func TestBaselineRanges(t *testing.T) {
+	window := 15 * time.Minute
+	windowStart := time.Date(2024, 3, 10, 11, 50, 0, 0, time.UTC)
+	windowEnd := windowStart.Add(window)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		history time.Duration
+		kind    BaselineKind
+		samples int
+		ranges  []TimeRange
+	}{
+		{
+			name:    "no history",
+			history: 0,
+			kind:    BaselineRolling,
+		},
+		{
+			name:    "rolling over the whole history",
+			history: 6 * time.Hour,
+			kind:    BaselineRolling,
+			samples: 24,
+			ranges:  []TimeRange{{Since: windowStart.Add(-6 * time.Hour), Until: windowStart}},
+		},
+		{
+			name:    "rolling over partial windows",
+			history: 40 * time.Minute,
+			kind:    BaselineRolling,
+			samples: 2,
+			ranges:  []TimeRange{{Since: windowStart.Add(-30 * time.Minute), Until: windowStart}},
+		},
+		{
+			name:    "rolling is capped to a day",
+			history: 2 * day,
+			kind:    BaselineRolling,
+			samples: 96,
+			ranges:  []TimeRange{{Since: windowStart.Add(-day), Until: windowStart}},
+		},
+		{
+			name:    "seasonal once there are three days",
+			history: 3 * day,
+			kind:    BaselineSeasonal,
+			samples: 27,
+			ranges: []TimeRange{
+				{Since: windowStart.Add(-day - time.Hour), Until: windowEnd.Add(-day + time.Hour)},
+				{Since: windowStart.Add(-2*day - time.Hour), Until: windowEnd.Add(-2*day + time.Hour)},
+				{Since: windowStart.Add(-3*day - time.Hour), Until: windowEnd.Add(-3*day + time.Hour)},
+			},
+		},
+		{
+			name:    "seasonal is capped to a week",
+			history: 30 * day,
+			kind:    BaselineSeasonal,
+			samples: 63,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ranges, samples, kind := baselineRanges(windowStart.Add(-test.history), windowStart, windowEnd, window)
+			if kind != test.kind {
+				t.Errorf("kind = %s, want %s", kind, test.kind)
+			}
+			if samples != test.samples {
+				t.Errorf("samples = %d, want %d", samples, test.samples)
+			}
+			if test.ranges != nil && !rangesEqual(ranges, test.ranges) {
+				t.Errorf("ranges = %v, want %v", ranges, test.ranges)
+			}
+			if test.kind == BaselineSeasonal && len(ranges) != min(int(test.history/day), seasonalDays) {
+				t.Errorf("got %d seasonal ranges for %s of history", len(ranges), test.history)
+			}
+		})
+	}
+}
+
+func TestValidWindow(t *testing.T) {
+	tests := []struct {
+		window time.Duration
+		valid  bool
+	}{
+		{window: 0, valid: false},
+		{window: time.Minute, valid: false},
+		{window: 5 * time.Minute, valid: true},
+		{window: 7 * time.Minute, valid: false},
+		{window: 15 * time.Minute, valid: true},
+		{window: 25 * time.Minute, valid: false},
+		{window: 30 * time.Minute, valid: true},
+		{window: time.Hour, valid: true},
+		{window: 2 * time.Hour, valid: false},
+	}
+
+	for _, test := range tests {
+		if valid := validWindow(test.window); valid != test.valid {
+			t.Errorf("validWindow(%s) = %t, want %t", test.window, valid, test.valid)
+		}
+	}
+}
+
+func anomaliesEqual(a, b Anomaly) bool {
+	const tolerance = 1e-9
+	return a.Namespace == b.Namespace && a.Reason == b.Reason &&
+		a.WindowStart.Equal(b.WindowStart) && a.WindowEnd.Equal(b.WindowEnd) &&
+		a.Occurrences == b.Occurrences && a.Baseline == b.Baseline &&
+		a.Samples == b.Samples && a.Severity == b.Severity &&
+		math.Abs(a.BaselineMean-b.BaselineMean) < tolerance &&
+		math.Abs(a.BaselineStdDev-b.BaselineStdDev) < tolerance &&
+		math.Abs(a.ZScore-b.ZScore) < tolerance
+}
+
+func rangesEqual(a, b []TimeRange) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Since.Equal(b[i].Since) || !a[i].Until.Equal(b[i].Until) {
+			return false
+		}
+	}
+	return true
+}
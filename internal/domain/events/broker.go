@@ -0,0 +1,146 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	subscriptionBufferSize = 256
+	// maxConsecutiveDrops is the number of events a subscriber may miss in a row before it is disconnected
+	maxConsecutiveDrops = 1024
+)
+
+var (
+	ErrSubscriberTooSlow = errors.New("subscriber is too slow")
+	ErrStreamClosed      = errors.New("event stream was closed, this replica stopped watching the cluster")
+)
+
+// SubscriptionFilter limits the events delivered to a subscriber.
+// Empty fields are not applied, Type is compared case-insensitively.
+type SubscriptionFilter struct {
+	Namespaces []string
+	Type       string
+}
+
+func (f SubscriptionFilter) Matches(event Event) bool {
+	if f.Type != "" && !strings.EqualFold(f.Type, event.Type) {
+		return false
+	}
+	if len(f.Namespaces) == 0 {
+		return true
+	}
+	for _, namespace := range f.Namespaces {
+		if namespace == event.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+type Subscription struct {
+	id               uint64
+	filter           SubscriptionFilter
+	events           chan Event
+	dropped          atomic.Int64
+	consecutiveDrops int
+	err              error
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns why the events channel was closed by the broker, it's nil after Unsubscribe.
+// It may only be called once the channel is closed.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Dropped returns the number of events skipped because the subscriber didn't keep up
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// EventBroker fans out received events to any number of in-process subscribers.
+// Publishing never blocks: a subscriber with a full buffer loses the event,
+// and a subscriber that keeps losing events is closed.
+type EventBroker struct {
+	mu            sync.RWMutex
+	nextID        uint64
+	subscriptions map[uint64]*Subscription
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{
+		subscriptions: make(map[uint64]*Subscription),
+	}
+}
+
+func (b *EventBroker) Subscribe(filter SubscriptionFilter) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	subscription := &Subscription{
+		id:     b.nextID,
+		filter: filter,
+		events: make(chan Event, subscriptionBufferSize),
+	}
+	b.subscriptions[subscription.id] = subscription
+	return subscription
+}
+
+func (b *EventBroker) Unsubscribe(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.subscriptions[subscription.id]; exists {
+		delete(b.subscriptions, subscription.id)
+		close(subscription.events)
+	}
+}
+
+func (b *EventBroker) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, subscription := range b.subscriptions {
+		if !subscription.filter.Matches(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+			subscription.consecutiveDrops = 0
+		default:
+			subscription.dropped.Add(1)
+			subscription.consecutiveDrops++
+			if subscription.consecutiveDrops >= maxConsecutiveDrops {
+				subscription.err = ErrSubscriberTooSlow
+				delete(b.subscriptions, id)
+				close(subscription.events)
+			}
+		}
+	}
+}
+
+// CloseAll disconnects every subscriber, used when events stop being published
+// so clients reconnect to the replica that publishes them
+func (b *EventBroker) CloseAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, subscription := range b.subscriptions {
+		subscription.err = ErrStreamClosed
+		delete(b.subscriptions, id)
+		close(subscription.events)
+	}
+}
+
+func (b *EventBroker) SubscribersCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscriptions)
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+type ObjectCondition struct {
+	Type               string     `json:"type"`
+	Status             string     `json:"status"`
+	Reason             string     `json:"reason,omitempty"`
+	Message            string     `json:"message,omitempty"`
+	LastTransitionTime *time.Time `json:"last_transition_time,omitempty"`
+}
+
+type ContainerState struct {
+	Name         string `json:"name"`
+	Init         bool   `json:"init"`
+	Image        string `json:"image"`
+	Ready        bool   `json:"ready"`
+	State        string `json:"state"`
+	Reason       string `json:"reason,omitempty"`
+	Message      string `json:"message,omitempty"`
+	RestartCount int32  `json:"restart_count"`
+	// LastTermination explains the previous restart, e.g. "OOMKilled (exit code 137)"
+	LastTermination string `json:"last_termination,omitempty"`
+}
+
+// ObjectDescription is a "kubectl describe"-like view of a pod or a node:
+// its current status together with the events that explain it
+type ObjectDescription struct {
+	Object     InvolvedObject    `json:"object"`
+	Status     string            `json:"status"`
+	Reason     string            `json:"reason,omitempty"`
+	Message    string            `json:"message,omitempty"`
+	Conditions []ObjectCondition `json:"conditions"`
+	Containers []ContainerState  `json:"containers,omitempty"`
+	Events     []Event           `json:"events"`
+	// LiveEventsError is set when only the stored events could be returned
+	LiveEventsError string `json:"live_events_error,omitempty"`
+}
@@ -3,13 +3,19 @@ package events
 import (
 	"context"
 	"main/pkg"
+	"time"
 
 	"go.uber.org/fx"
 )
 
 type EventRepository interface {
 	SaveEvent(event Event) error
-	GetEvents(namespace string, eventType string, limit int) ([]Event, error)
+	SaveEvents(events []Event) error
+	ListEvents(filter EventFilter) ([]Event, error)
+	CountEvents(filter EventFilter) (int, error)
+	ExportEvents(filter EventFilter, fn func(Event) error) error
+	CountEventsByBucket(query EventStatsQuery) ([]EventBucketCount, error)
+	GetTopInvolvedObjects(filter EventFilter, limit int) ([]InvolvedObjectCount, error)
 }
 
 type WatchedNamespaceRepository interface {
@@ -20,90 +26,141 @@ type WatchedNamespaceRepository interface {
 
 type EventService struct {
 	logger              pkg.Logger
-	k8sClient           EventsKubernetesClient
 	repository          EventRepository
 	namespaceRepository WatchedNamespaceRepository
-	watchedNamespaces   map[string]context.CancelFunc
+	broker              *EventBroker
+	watchManager        *WatchManager
+	k8sClient           EventsKubernetesClient
 }
 
 var Module = fx.Module("events",
+	fx.Provide(NewEventBroker),
+	fx.Provide(NewEventIngester),
+	fx.Provide(NewWatchManager),
 	fx.Provide(NewEventService),
 )
 
-func NewEventService(logger pkg.Logger, k8sClient EventsKubernetesClient, repo EventRepository, namespaceRepo WatchedNamespaceRepository) EventService {
-	svc := EventService{
+func NewEventService(logger pkg.Logger, repo EventRepository, namespaceRepo WatchedNamespaceRepository, broker *EventBroker, watchManager *WatchManager, k8sClient EventsKubernetesClient) *EventService {
+	return &EventService{
 		logger:              logger,
-		k8sClient:           k8sClient,
 		repository:          repo,
 		namespaceRepository: namespaceRepo,
-		watchedNamespaces:   make(map[string]context.CancelFunc),
+		broker:              broker,
+		watchManager:        watchManager,
+		k8sClient:           k8sClient,
 	}
+}
+
+// AddNamespaceToWatch validates and stores the namespace, the number of backfilled events is returned
+func (s *EventService) AddNamespaceToWatch(ctx context.Context, namespace string) (int, error) {
+	return s.watchManager.Add(ctx, namespace)
+}
+
+func (s *EventService) RemoveNamespaceFromWatch(namespace string) error {
+	return s.watchManager.Remove(namespace)
+}
 
-	namespaces, err := namespaceRepo.GetAllNamespaces()
+func (s *EventService) Subscribe(filter SubscriptionFilter) *Subscription {
+	return s.broker.Subscribe(filter)
+}
+
+func (s *EventService) Unsubscribe(subscription *Subscription) {
+	s.broker.Unsubscribe(subscription)
+}
+
+func (s *EventService) GetWatchedNamespaces() ([]string, error) {
+	return s.namespaceRepository.GetAllNamespaces()
+}
+
+// GetWatcherStatuses reports the watchers of this replica for every watched namespace
+func (s *EventService) GetWatcherStatuses() ([]WatcherStatus, error) {
+	namespaces, err := s.namespaceRepository.GetAllNamespaces()
 	if err != nil {
-		logger.Errorf("Failed to get watched namespaces: %v", err)
-	} else {
-		for _, namespace := range namespaces {
-			if err := svc.StartWatching(context.Background(), namespace); err != nil {
-				logger.Errorf("Failed to start watching namespace %s: %v", namespace, err)
-			}
-		}
+		return nil, err
 	}
 
-	return svc
+	return s.watchManager.Statuses(namespaces), nil
 }
 
-func (s *EventService) StartWatching(ctx context.Context, namespace string) error {
-	s.logger.Info("Starting event watching in namespace", namespace)
+func (s *EventService) ListEvents(filter EventFilter) (EventPage, error) {
+	if filter.Order == "" {
+		filter.Order = SortOrderDesc
+	}
 
-	watcherCtx, cancel := context.WithCancel(ctx)
-	s.watchedNamespaces[namespace] = cancel
+	total, err := s.repository.CountEvents(filter)
+	if err != nil {
+		return EventPage{}, err
+	}
 
-	eventChan, err := s.k8sClient.WatchEvents(watcherCtx, namespace)
+	// Request one extra row to find out whether there is a next page
+	pageFilter := filter
+	pageFilter.Limit = filter.Limit + 1
+	foundEvents, err := s.repository.ListEvents(pageFilter)
 	if err != nil {
-		s.logger.Errorf("Failed to start watching events: %v", err)
-		return err
+		return EventPage{}, err
 	}
 
-	go func() {
-		for event := range eventChan {
-			s.logger.Info("Received event in namespace", namespace, "event name:", event.Name)
-			if err := s.repository.SaveEvent(event); err != nil {
-				s.logger.Errorf("Failed to save event %s: %v", event.Name, err)
-			}
-		}
-		s.logger.Info("Event channel closed on namespace", namespace)
-	}()
+	page := EventPage{
+		Events: foundEvents,
+		Total:  total,
+	}
+	if len(foundEvents) > filter.Limit {
+		page.Events = foundEvents[:filter.Limit]
+		page.NextCursor = NewEventCursor(page.Events[len(page.Events)-1]).Encode()
+	}
 
-	return nil
+	return page, nil
 }
 
-func (s *EventService) StopWatching(namespace string) {
-	if cancel, exists := s.watchedNamespaces[namespace]; exists {
-		cancel()
-		delete(s.watchedNamespaces, namespace)
+// ExportEvents calls fn for every event matching the filter, a zero limit exports all of them
+func (s *EventService) ExportEvents(filter EventFilter, fn func(Event) error) error {
+	if filter.Order == "" {
+		filter.Order = SortOrderDesc
 	}
+	filter.Cursor = nil
+	return s.repository.ExportEvents(filter, fn)
 }
 
-func (s *EventService) AddNamespaceToWatch(ctx context.Context, namespace string) error {
-	if err := s.namespaceRepository.AddNamespace(namespace); err != nil {
-		return err
+func (s *EventService) GetEventStats(query EventStatsQuery) (EventStats, error) {
+	rows, err := s.repository.CountEventsByBucket(query)
+	if err != nil {
+		return EventStats{}, err
 	}
-	return s.StartWatching(ctx, namespace)
-}
 
-func (s *EventService) RemoveNamespaceFromWatch(namespace string) error {
-	if err := s.namespaceRepository.RemoveNamespace(namespace); err != nil {
-		return err
+	stats := EventStats{
+		Interval:   query.Interval.String(),
+		GroupBy:    query.GroupBy,
+		Buckets:    make([]time.Time, 0),
+		Series:     make([]EventSeries, 0),
+		TopObjects: make([]InvolvedObjectCount, 0),
 	}
-	s.StopWatching(namespace)
-	return nil
-}
 
-func (s *EventService) GetWatchedNamespaces() ([]string, error) {
-	return s.namespaceRepository.GetAllNamespaces()
-}
+	// Rows are ordered by key and then by bucket, every key has a row for each bucket
+	bucketIndex := make(map[time.Time]int)
+	seriesIndex := make(map[string]int)
+	for _, row := range rows {
+		if _, exists := bucketIndex[row.Bucket]; !exists {
+			bucketIndex[row.Bucket] = len(stats.Buckets)
+			stats.Buckets = append(stats.Buckets, row.Bucket)
+		}
+		if row.Key == nil {
+			continue
+		}
+		if _, exists := seriesIndex[*row.Key]; !exists {
+			seriesIndex[*row.Key] = len(stats.Series)
+			stats.Series = append(stats.Series, EventSeries{Key: *row.Key, Counts: make([]int, 0)})
+		}
+		series := &stats.Series[seriesIndex[*row.Key]]
+		series.Counts = append(series.Counts, row.Count)
+		series.Total += row.Count
+	}
+
+	if query.Top > 0 {
+		stats.TopObjects, err = s.repository.GetTopInvolvedObjects(query.Filter, query.Top)
+		if err != nil {
+			return EventStats{}, err
+		}
+	}
 
-func (s *EventService) GetEvents(namespace string, eventType string, limit int) ([]Event, error) {
-	return s.repository.GetEvents(namespace, eventType, limit)
+	return stats, nil
 }
@@ -5,24 +5,94 @@ import (
 	"time"
 )
 
+const (
+	// EventSourceKubernetes marks events received from the API server
+	EventSourceKubernetes = "kubernetes"
+	// EventSourceSynthetic marks events generated from pod and node state transitions
+	EventSourceSynthetic = "synthetic"
+)
+
+// Event is a stored cluster event. InvolvedObject keeps the "Kind/Name" form for
+// compatibility, the involved object is also stored field by field together with
+// the workload that owns it.
 type Event struct {
-	ID             string    `json:"id" db:"id"`
-	Namespace      string    `json:"namespace" db:"namespace"`
-	Name           string    `json:"name" db:"name"`
-	Reason         string    `json:"reason" db:"reason"`
-	Message        string    `json:"message" db:"message"`
-	Type           string    `json:"type" db:"type"`
-	InvolvedObject string    `json:"involved_object" db:"involved_object"`
-	FirstTimestamp time.Time `json:"first_timestamp" db:"first_timestamp"`
-	LastTimestamp  time.Time `json:"last_timestamp" db:"last_timestamp"`
-	Count          int32     `json:"count" db:"count"`
+	ID                 string    `json:"id" db:"id"`
+	Namespace          string    `json:"namespace" db:"namespace"`
+	Name               string    `json:"name" db:"name"`
+	Reason             string    `json:"reason" db:"reason"`
+	Message            string    `json:"message" db:"message"`
+	Type               string    `json:"type" db:"type"`
+	InvolvedObject     string    `json:"involved_object" db:"involved_object"`
+	InvolvedKind       string    `json:"involved_kind" db:"involved_kind"`
+	InvolvedName       string    `json:"involved_name" db:"involved_name"`
+	InvolvedNamespace  string    `json:"involved_namespace" db:"involved_namespace"`
+	InvolvedUID        string    `json:"involved_uid" db:"involved_uid"`
+	InvolvedAPIVersion string    `json:"involved_api_version" db:"involved_api_version"`
+	InvolvedFieldPath  string    `json:"involved_field_path" db:"involved_field_path"`
+	OwnerKind          string    `json:"owner_kind" db:"owner_kind"`
+	OwnerName          string    `json:"owner_name" db:"owner_name"`
+	FirstTimestamp     time.Time `json:"first_timestamp" db:"first_timestamp"`
+	LastTimestamp      time.Time `json:"last_timestamp" db:"last_timestamp"`
+	Count              int32     `json:"count" db:"count"`
+	Source             string    `json:"source" db:"source"`
+	// Replayed marks events listed again after the watch expired, they are saved but not published twice
+	Replayed bool `json:"-" db:"-"`
 }
 
 type InvolvedObject struct {
-	Kind string `json:"kind"`
-	Name string `json:"name"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	UID        string `json:"uid,omitempty"`
+	APIVersion string `json:"api_version,omitempty"`
+	FieldPath  string `json:"field_path,omitempty"`
+}
+
+func (e *Event) SetInvolvedObject(object InvolvedObject) {
+	e.InvolvedObject = object.Kind + "/" + object.Name
+	e.InvolvedKind = object.Kind
+	e.InvolvedName = object.Name
+	e.InvolvedNamespace = object.Namespace
+	e.InvolvedUID = object.UID
+	e.InvolvedAPIVersion = object.APIVersion
+	e.InvolvedFieldPath = object.FieldPath
+}
+
+func (e Event) Involved() InvolvedObject {
+	return InvolvedObject{
+		Kind:       e.InvolvedKind,
+		Name:       e.InvolvedName,
+		Namespace:  e.InvolvedNamespace,
+		UID:        e.InvolvedUID,
+		APIVersion: e.InvolvedAPIVersion,
+		FieldPath:  e.InvolvedFieldPath,
+	}
+}
+
+// SetOwner records the top-level workload controlling the involved object,
+// objects without a controller are their own owner
+func (e *Event) SetOwner(owner InvolvedObject) {
+	e.OwnerKind = owner.Kind
+	e.OwnerName = owner.Name
+}
+
+// NamespaceChange is a namespace creation or deletion in the cluster,
+// Selected is set when the namespace matches the auto-watch label selector
+type NamespaceChange struct {
+	Namespace string
+	Deleted   bool
+	Selected  bool
 }
 
 type EventsKubernetesClient interface {
-	WatchEvents(ctx context.Context, namespace string) (chan Event, error)
+	GetNamespaces() ([]string, error)
+	WatchNamespaces(ctx context.Context, selector string) (chan NamespaceChange, error)
+	ListEvents(ctx context.Context, namespace string) ([]Event, string, error)
+	WatchEvents(ctx context.Context, namespace string, resourceVersion string, observer WatchObserver) (chan Event, error)
+	WatchPodLifecycle(ctx context.Context, namespace string, pendingThreshold time.Duration) (chan Event, error)
+	WatchNodeLifecycle(ctx context.Context) (chan Event, error)
+	ListObjectEvents(ctx context.Context, object InvolvedObject) ([]Event, error)
+	ResolveOwners(ctx context.Context, events []Event)
+	DescribePod(ctx context.Context, namespace string, name string) (*ObjectDescription, error)
+	DescribeNode(ctx context.Context, name string) (*ObjectDescription, error)
 }
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+type SortOrder string
+
+const (
+	SortOrderAsc  SortOrder = "asc"
+	SortOrderDesc SortOrder = "desc"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EventFilter describes which stored events should be returned by the list API.
+// Empty fields are not applied, an empty Namespaces slice means all namespaces.
+// With IncludeOwned the involved kind and name also match events of objects owned by it.
+type EventFilter struct {
+	Namespaces   []string
+	Type         string
+	Reason       string
+	InvolvedKind string
+	InvolvedName string
+	IncludeOwned bool
+	OwnerKind    string
+	OwnerName    string
+	Source       string
+	Since        *time.Time
+	Until        *time.Time
+	Order        SortOrder
+	Limit        int
+	Cursor       *EventCursor
+}
+
+type EventPage struct {
+	Events     []Event `json:"events"`
+	Total      int     `json:"total"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// EventCursor points at the last event of a page, pagination continues strictly after it
+// in the (last_timestamp, id) order.
+type EventCursor struct {
+	LastTimestamp time.Time
+	ID            string
+}
+
+func NewEventCursor(event Event) EventCursor {
+	return EventCursor{
+		LastTimestamp: event.LastTimestamp,
+		ID:            event.ID,
+	}
+}
+
+func (c EventCursor) Encode() string {
+	raw := c.LastTimestamp.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeEventCursor(value string) (*EventCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	timestamp, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	lastTimestamp, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &EventCursor{
+		LastTimestamp: lastTimestamp,
+		ID:            id,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor EventCursor
+	}{
+		{
+			name:   "seconds",
+			cursor: EventCursor{LastTimestamp: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), ID: "3f1c0e9a"},
+		},
+		{
+			name:   "nanoseconds",
+			cursor: EventCursor{LastTimestamp: time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC), ID: "3f1c0e9a"},
+		},
+		{
+			name:   "non utc timestamp",
+			cursor: EventCursor{LastTimestamp: time.Date(2024, 3, 1, 15, 30, 0, 0, time.FixedZone("MSK", 3*60*60)), ID: "3f1c0e9a"},
+		},
+		{
+			name:   "id containing the separator",
+			cursor: EventCursor{LastTimestamp: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), ID: "pod|warning"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, err := DecodeEventCursor(test.cursor.Encode())
+			if err != nil {
+				t.Fatalf("failed to decode cursor: %v", err)
+			}
+			if !decoded.LastTimestamp.Equal(test.cursor.LastTimestamp) {
+				t.Errorf("last timestamp = %s, want %s", decoded.LastTimestamp, test.cursor.LastTimestamp)
+			}
+			if decoded.ID != test.cursor.ID {
+				t.Errorf("id = %q, want %q", decoded.ID, test.cursor.ID)
+			}
+		})
+	}
+}
+
+func TestDecodeEventCursorRejectsMalformed(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "empty", value: ""},
+		{name: "not base64", value: "not a cursor!"},
+		{name: "padded base64", value: base64.URLEncoding.EncodeToString([]byte("2024-03-01T12:30:00Z|id"))},
+		{name: "missing separator", value: encode("2024-03-01T12:30:00Z")},
+		{name: "missing id", value: encode("2024-03-01T12:30:00Z|")},
+		{name: "invalid timestamp", value: encode("yesterday|id")},
+		{name: "missing timestamp", value: encode("|id")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cursor, err := DecodeEventCursor(test.value)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("error = %v, want %v", err, ErrInvalidCursor)
+			}
+			if cursor != nil {
+				t.Errorf("cursor = %+v, want nil", cursor)
+			}
+		})
+	}
+}
+
+func TestNewEventCursor(t *testing.T) {
+	event := Event{ID: "3f1c0e9a", LastTimestamp: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)}
+
+	cursor := NewEventCursor(event)
+	if cursor.ID != event.ID || !cursor.LastTimestamp.Equal(event.LastTimestamp) {
+		t.Errorf("cursor = %+v, want the id and last timestamp of %+v", cursor, event)
+	}
+}
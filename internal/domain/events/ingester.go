@@ -0,0 +1,205 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"main/pkg"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+)
+
+const (
+	ingestQueueSize     = 4096
+	ingestBatchSize     = 200
+	ingestFlushInterval = time.Second
+	// Owner lookups of a batch are cached by the client, a cold cache shouldn't stall saving
+	ownerResolveTimeout = 10 * time.Second
+)
+
+var ErrIngesterStopped = errors.New("event ingester is stopped")
+
+var (
+	ingestQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "event_ingest_queue_depth",
+		Help: "Number of received events waiting to be saved.",
+	})
+	ingestSavedEvents = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "event_ingest_saved_events_total",
+		Help: "Number of events saved to the database.",
+	})
+	ingestFailedEvents = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "event_ingest_failed_events_total",
+		Help: "Number of events that couldn't be saved to the database.",
+	})
+	ingestBackpressure = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "event_ingest_backpressure_total",
+		Help: "Number of times a watcher had to wait because the ingest queue was full.",
+	})
+	ingestFlushDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "event_ingest_flush_duration_seconds",
+		Help:    "Time spent saving a single batch of events.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	prometheus.MustRegister(ingestQueueDepth, ingestSavedEvents, ingestFailedEvents, ingestBackpressure, ingestFlushDuration)
+}
+
+// EventIngester buffers received events and saves them in batches.
+// A batch is written when it reaches ingestBatchSize or after ingestFlushInterval,
+// whichever comes first. When the queue is full Enqueue blocks, which slows down
+// the watchers instead of growing memory without bound.
+type EventIngester struct {
+	logger     pkg.Logger
+	repository EventRepository
+	k8sClient  EventsKubernetesClient
+	queue      chan Event
+	mu         sync.RWMutex
+	stopped    bool
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func NewEventIngester(lc fx.Lifecycle, logger pkg.Logger, repository EventRepository, k8sClient EventsKubernetesClient) *EventIngester {
+	ingester := &EventIngester{
+		logger:     logger,
+		repository: repository,
+		k8sClient:  k8sClient,
+		queue:      make(chan Event, ingestQueueSize),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go ingester.run()
+			return nil
+		},
+		OnStop: ingester.Stop,
+	})
+
+	return ingester
+}
+
+func (i *EventIngester) Enqueue(ctx context.Context, event Event) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.stopped {
+		return ErrIngesterStopped
+	}
+
+	select {
+	case i.queue <- event:
+		ingestQueueDepth.Set(float64(len(i.queue)))
+		return nil
+	default:
+	}
+
+	ingestBackpressure.Inc()
+	select {
+	case i.queue <- event:
+		ingestQueueDepth.Set(float64(len(i.queue)))
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop rejects new events and waits until everything already queued is saved
+func (i *EventIngester) Stop(ctx context.Context) error {
+	i.mu.Lock()
+	if i.stopped {
+		i.mu.Unlock()
+		return nil
+	}
+	i.stopped = true
+	i.mu.Unlock()
+
+	close(i.stop)
+
+	select {
+	case <-i.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (i *EventIngester) QueueDepth() int {
+	return len(i.queue)
+}
+
+func (i *EventIngester) run() {
+	defer close(i.done)
+
+	ticker := time.NewTicker(ingestFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, ingestBatchSize)
+	for {
+		select {
+		case event := <-i.queue:
+			batch = append(batch, event)
+			if len(batch) >= ingestBatchSize {
+				batch = i.flush(batch)
+			}
+		case <-ticker.C:
+			batch = i.flush(batch)
+		case <-i.stop:
+			// Enqueue can't add anything once stopped is set, so the queue only shrinks
+			for {
+				select {
+				case event := <-i.queue:
+					batch = append(batch, event)
+					if len(batch) >= ingestBatchSize {
+						batch = i.flush(batch)
+					}
+				default:
+					i.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (i *EventIngester) flush(batch []Event) []Event {
+	ingestQueueDepth.Set(float64(len(i.queue)))
+	if len(batch) == 0 {
+		return batch
+	}
+
+	start := time.Now()
+	resolveOwners(i.k8sClient, batch)
+	err := i.repository.SaveEvents(batch)
+	ingestFlushDuration.Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		ingestSavedEvents.Add(float64(len(batch)))
+		return batch[:0]
+	}
+
+	// Retry one by one so a single bad event doesn't lose the whole batch
+	i.logger.Errorf("Failed to save batch of %d events, retrying one by one: %v", len(batch), err)
+	for _, event := range batch {
+		if err := i.repository.SaveEvent(event); err != nil {
+			ingestFailedEvents.Inc()
+			i.logger.Errorf("Failed to save event %s: %v", event.Name, err)
+			continue
+		}
+		ingestSavedEvents.Inc()
+	}
+	return batch[:0]
+}
+
+// resolveOwners looks up the owners of a batch before it's saved, so watchers never wait on the API server
+func resolveOwners(k8sClient EventsKubernetesClient, batch []Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), ownerResolveTimeout)
+	defer cancel()
+	k8sClient.ResolveOwners(ctx, batch)
+}
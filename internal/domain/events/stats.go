@@ -0,0 +1,59 @@
+package events
+
+import "time"
+
+type StatsGroupBy string
+
+const (
+	StatsGroupByReason    StatsGroupBy = "reason"
+	StatsGroupByType      StatsGroupBy = "type"
+	StatsGroupByNamespace StatsGroupBy = "namespace"
+	StatsGroupByKind      StatsGroupBy = "kind"
+	StatsGroupBySource    StatsGroupBy = "source"
+)
+
+func (g StatsGroupBy) IsValid() bool {
+	switch g {
+	case StatsGroupByReason, StatsGroupByType, StatsGroupByNamespace, StatsGroupByKind, StatsGroupBySource:
+		return true
+	}
+	return false
+}
+
+// EventStatsQuery describes a histogram over the events matching Filter.
+// Filter.Since and Filter.Until are required, they define the histogram range.
+type EventStatsQuery struct {
+	Filter   EventFilter
+	Interval time.Duration
+	GroupBy  StatsGroupBy
+	Top      int
+}
+
+// EventBucketCount is a single row of the histogram, buckets without events are returned with zero count.
+// Key is nil only when nothing matched the filter in the whole range.
+type EventBucketCount struct {
+	Bucket time.Time `db:"bucket"`
+	Key    *string   `db:"key"`
+	Count  int       `db:"count"`
+}
+
+type InvolvedObjectCount struct {
+	Namespace      string `json:"namespace" db:"namespace"`
+	InvolvedObject string `json:"involved_object" db:"involved_object"`
+	Events         int    `json:"events" db:"events"`
+	Occurrences    int64  `json:"occurrences" db:"occurrences"`
+}
+
+type EventSeries struct {
+	Key    string `json:"key"`
+	Counts []int  `json:"counts"`
+	Total  int    `json:"total"`
+}
+
+type EventStats struct {
+	Interval   string                `json:"interval"`
+	GroupBy    StatsGroupBy          `json:"group_by"`
+	Buckets    []time.Time           `json:"buckets"`
+	Series     []EventSeries         `json:"series"`
+	TopObjects []InvolvedObjectCount `json:"top_objects"`
+}
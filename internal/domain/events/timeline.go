@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"sort"
+)
+
+// DescribePod returns the pod status with the latest events of the pod and of anything it owns
+func (s *EventService) DescribePod(ctx context.Context, namespace string, name string, limit int) (*ObjectDescription, error) {
+	description, err := s.k8sClient.DescribePod(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := EventFilter{
+		Namespaces:   []string{namespace},
+		InvolvedKind: "Pod",
+		InvolvedName: name,
+		IncludeOwned: true,
+		Limit:        limit,
+	}
+	return s.withTimeline(ctx, description, filter)
+}
+
+// DescribeNode returns the node status with its latest events, node events aren't
+// bound to the namespace they are recorded in
+func (s *EventService) DescribeNode(ctx context.Context, name string, limit int) (*ObjectDescription, error) {
+	description, err := s.k8sClient.DescribeNode(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := EventFilter{
+		InvolvedKind: "Node",
+		InvolvedName: name,
+		IncludeOwned: true,
+		Limit:        limit,
+	}
+	return s.withTimeline(ctx, description, filter)
+}
+
+// withTimeline merges the stored events with the ones the API server currently holds,
+// the live version of an event wins since its count and timestamps are the most recent.
+// Live events are optional, the stored ones are still returned when they can't be listed.
+func (s *EventService) withTimeline(ctx context.Context, description *ObjectDescription, filter EventFilter) (*ObjectDescription, error) {
+	filter.Order = SortOrderDesc
+	stored, err := s.repository.ListEvents(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := s.k8sClient.ListObjectEvents(ctx, description.Object)
+	if err != nil {
+		s.logger.Errorf("failed to list live events for %s/%s: %v", description.Object.Kind, description.Object.Name, err)
+		description.LiveEventsError = err.Error()
+	}
+
+	merged := make(map[string]Event, len(stored)+len(live))
+	for _, event := range stored {
+		merged[event.ID] = event
+	}
+	for _, event := range live {
+		if existing, ok := merged[event.ID]; ok && event.OwnerKind == "" {
+			event.OwnerKind = existing.OwnerKind
+			event.OwnerName = existing.OwnerName
+		}
+		merged[event.ID] = event
+	}
+
+	timeline := make([]Event, 0, len(merged))
+	for _, event := range merged {
+		timeline = append(timeline, event)
+	}
+	sort.Slice(timeline, func(i, j int) bool {
+		if timeline[i].LastTimestamp.Equal(timeline[j].LastTimestamp) {
+			return timeline[i].ID > timeline[j].ID
+		}
+		return timeline[i].LastTimestamp.After(timeline[j].LastTimestamp)
+	})
+	if filter.Limit > 0 && len(timeline) > filter.Limit {
+		timeline = timeline[:filter.Limit]
+	}
+
+	description.Events = timeline
+	return description, nil
+}
@@ -0,0 +1,543 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/internal/config"
+	"main/internal/domain/leadership"
+	"main/pkg"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+var (
+	ErrInvalidNamespace  = errors.New("namespace is required")
+	ErrNamespaceNotFound = errors.New("namespace not found")
+)
+
+// watcherResyncInterval is how often the leader picks up namespaces added or removed through other replicas
+const watcherResyncInterval = 30 * time.Second
+
+// WatchManager owns the namespace watchers. Watchers run only between the fx start and stop
+// while this replica is the leader. Changes to the set of watchers are serialized, so a namespace
+// is never watched twice, while backfills run outside the lock so a slow list doesn't block them.
+type WatchManager struct {
+	logger              pkg.Logger
+	k8sClient           EventsKubernetesClient
+	repository          EventRepository
+	namespaceRepository WatchedNamespaceRepository
+	broker              *EventBroker
+	ingester            *EventIngester
+	leader              leadership.Elector
+	pendingThreshold    time.Duration
+	namespaceSelector   string
+
+	// ops serializes changes to the watchers
+	ops sync.Mutex
+	// removals counts the removals of each namespace, a watcher started before one isn't registered
+	removals map[string]int
+	// lifecycle serializes lead and halt
+	lifecycle sync.Mutex
+
+	mu         sync.RWMutex
+	running    bool
+	leaderCtx  context.Context
+	leadCtx    context.Context
+	leadCancel context.CancelFunc
+	watchers   map[string]*namespaceWatcher
+	health     map[string]*watcherHealth
+
+	// background tracks the goroutines that live as long as the leadership
+	background sync.WaitGroup
+}
+
+type namespaceWatcher struct {
+	cancel    context.CancelFunc
+	consumers sync.WaitGroup
+}
+
+// stop cancels the watcher and waits until the events it already received are queued
+func (w *namespaceWatcher) stop() {
+	w.cancel()
+	w.consumers.Wait()
+}
+
+func NewWatchManager(lc fx.Lifecycle, env config.Env, logger pkg.Logger, k8sClient EventsKubernetesClient, repo EventRepository, namespaceRepo WatchedNamespaceRepository, broker *EventBroker, ingester *EventIngester, leader leadership.Elector) *WatchManager {
+	pendingThreshold, err := time.ParseDuration(env.PodPendingThreshold)
+	if err != nil {
+		logger.Errorf("Invalid POD_PENDING_THRESHOLD %q, pending pods won't be reported: %v", env.PodPendingThreshold, err)
+	}
+
+	manager := &WatchManager{
+		logger:              logger,
+		k8sClient:           k8sClient,
+		repository:          repo,
+		namespaceRepository: namespaceRepo,
+		broker:              broker,
+		ingester:            ingester,
+		leader:              leader,
+		pendingThreshold:    pendingThreshold,
+		namespaceSelector:   env.AutoWatchNamespaceSelector,
+		watchers:            make(map[string]*namespaceWatcher),
+		health:              make(map[string]*watcherHealth),
+		removals:            make(map[string]int),
+	}
+
+	// Every replica serves the read API, only the leader watches namespaces
+	leader.OnStartedLeading(manager.startLeading)
+	leader.OnStoppedLeading(manager.halt)
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			manager.start()
+			return nil
+		},
+		OnStop: manager.stop,
+	})
+
+	return manager
+}
+
+func (m *WatchManager) start() {
+	m.mu.Lock()
+	m.running = true
+	m.mu.Unlock()
+	m.lead()
+}
+
+// stop cancels every watcher and waits for them to drain into the ingester,
+// which is stopped after the manager and flushes what is left
+func (m *WatchManager) stop(ctx context.Context) error {
+	m.mu.Lock()
+	m.running = false
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.halt()
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *WatchManager) startLeading(ctx context.Context) {
+	m.mu.Lock()
+	m.leaderCtx = ctx
+	m.mu.Unlock()
+	m.lead()
+}
+
+// lead starts the watchers once the manager is both started and leading,
+// the leader election may win before or after the fx start
+func (m *WatchManager) lead() {
+	m.lifecycle.Lock()
+	defer m.lifecycle.Unlock()
+
+	m.mu.Lock()
+	if !m.running || m.leaderCtx == nil || m.leadCtx != nil {
+		m.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(m.leaderCtx)
+	m.leadCtx = ctx
+	m.leadCancel = cancel
+	m.mu.Unlock()
+
+	namespaceChanges, err := m.k8sClient.WatchNamespaces(ctx, m.namespaceSelector)
+	if err != nil {
+		m.logger.Errorf("Failed to start watching namespaces: %v", err)
+	} else {
+		m.background.Add(1)
+		go func() {
+			defer m.background.Done()
+			m.manageNamespaces(ctx, namespaceChanges)
+		}()
+	}
+
+	nodeEvents, err := m.k8sClient.WatchNodeLifecycle(ctx)
+	if err != nil {
+		m.logger.Errorf("Failed to start watching node lifecycle: %v", err)
+	} else {
+		m.background.Add(1)
+		go func() {
+			defer m.background.Done()
+			m.consume(nodeEvents)
+		}()
+	}
+
+	m.background.Add(1)
+	go func() {
+		defer m.background.Done()
+		// The first sync lists the namespaces from the database, it doesn't hold up the start
+		m.sync()
+		ticker := time.NewTicker(watcherResyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.sync()
+			}
+		}
+	}()
+}
+
+// halt stops everything started by lead, it's used both when the leadership is lost and on shutdown
+func (m *WatchManager) halt() {
+	m.lifecycle.Lock()
+	defer m.lifecycle.Unlock()
+
+	m.mu.Lock()
+	cancel := m.leadCancel
+	m.leaderCtx = nil
+	m.leadCtx = nil
+	m.leadCancel = nil
+	m.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	// Only the leader publishes, streams on this replica would stay silent
+	m.broker.CloseAll()
+
+	m.ops.Lock()
+	m.mu.Lock()
+	watchers := m.watchers
+	m.watchers = make(map[string]*namespaceWatcher)
+	clear(m.health)
+	m.mu.Unlock()
+	for _, watcher := range watchers {
+		watcher.stop()
+	}
+	m.ops.Unlock()
+
+	m.background.Wait()
+}
+
+func (m *WatchManager) currentLeadCtx() context.Context {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.leadCtx
+}
+
+// Add stores the namespace and starts its watcher if this replica is the leader,
+// otherwise the namespace is only backfilled and the leader picks it up on the next resync.
+// Adding an already watched namespace does nothing. The number of backfilled events is returned.
+func (m *WatchManager) Add(ctx context.Context, namespace string) (int, error) {
+	if err := m.validateNamespace(namespace); err != nil {
+		return 0, err
+	}
+
+	m.ops.Lock()
+	if err := m.namespaceRepository.AddNamespace(namespace); err != nil {
+		m.ops.Unlock()
+		return 0, err
+	}
+	leadCtx := m.currentLeadCtx()
+	watched := m.IsWatched(namespace)
+	removals := m.removals[namespace]
+	m.ops.Unlock()
+
+	if leadCtx == nil {
+		backfilled, _, err := m.backfill(ctx, namespace)
+		return backfilled, err
+	}
+	if watched {
+		return 0, nil
+	}
+	return m.startWatching(leadCtx, namespace, removals)
+}
+
+func (m *WatchManager) Remove(namespace string) error {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+
+	if err := m.namespaceRepository.RemoveNamespace(namespace); err != nil {
+		return err
+	}
+	m.removals[namespace]++
+	m.stopWatching(namespace)
+	return nil
+}
+
+func (m *WatchManager) IsWatched(namespace string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, exists := m.watchers[namespace]
+	return exists
+}
+
+// Statuses reports the watchers of this replica for the given namespaces,
+// a follower reports them in standby together with the leader watching them
+func (m *WatchManager) Statuses(namespaces []string) []WatcherStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]WatcherStatus, 0, len(namespaces))
+	if m.leadCtx == nil && !m.leader.IsLeader() {
+		leader := m.leader.Status().Leader
+		for _, namespace := range namespaces {
+			statuses = append(statuses, WatcherStatus{Namespace: namespace, State: WatcherStateStandby, Leader: leader})
+		}
+		return statuses
+	}
+	for _, namespace := range namespaces {
+		if health, exists := m.health[namespace]; exists {
+			statuses = append(statuses, health.Status())
+			continue
+		}
+		statuses = append(statuses, newWatcherHealth(namespace).Status())
+	}
+	return statuses
+}
+
+func (m *WatchManager) validateNamespace(namespace string) error {
+	if strings.TrimSpace(namespace) == "" {
+		return ErrInvalidNamespace
+	}
+
+	namespaces, err := m.k8sClient.GetNamespaces()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(namespaces, namespace) {
+		return fmt.Errorf("%w: %s", ErrNamespaceNotFound, namespace)
+	}
+	return nil
+}
+
+// sync makes the running watchers match the watched_namespaces table
+func (m *WatchManager) sync() {
+	m.ops.Lock()
+	leadCtx := m.currentLeadCtx()
+	if leadCtx == nil {
+		m.ops.Unlock()
+		return
+	}
+
+	namespaces, err := m.namespaceRepository.GetAllNamespaces()
+	if err != nil {
+		m.ops.Unlock()
+		m.logger.Errorf("Failed to get watched namespaces: %v", err)
+		return
+	}
+
+	wanted := make(map[string]struct{}, len(namespaces))
+	missing := make(map[string]int)
+	for _, namespace := range namespaces {
+		wanted[namespace] = struct{}{}
+		if !m.IsWatched(namespace) {
+			missing[namespace] = m.removals[namespace]
+		}
+	}
+
+	m.mu.RLock()
+	unwanted := make([]string, 0)
+	for namespace := range m.health {
+		if _, exists := wanted[namespace]; !exists {
+			unwanted = append(unwanted, namespace)
+			continue
+		}
+		if status := m.health[namespace].Status(); status.Warning != "" {
+			m.logger.Warnf("Watcher in namespace %s is unhealthy: %s, last error: %s", namespace, status.Warning, status.LastError)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, namespace := range unwanted {
+		m.stopWatching(namespace)
+	}
+	m.ops.Unlock()
+
+	for namespace, removals := range missing {
+		if _, err := m.startWatching(leadCtx, namespace, removals); err != nil {
+			m.logger.Errorf("Failed to start watching namespace %s: %v", namespace, err)
+		}
+	}
+}
+
+// startWatching must be called without ops held, removals is the count of the namespace
+// read together with the decision to watch it.
+// The namespace is backfilled first and the watch continues from the listed resourceVersion,
+// the number of backfilled events is returned. Nothing is started when the leadership changed,
+// the namespace was removed or another call started watching it during the backfill.
+func (m *WatchManager) startWatching(leadCtx context.Context, namespace string, removals int) (int, error) {
+	m.logger.Info("Starting event watching in namespace", namespace)
+
+	// Failed watchers are retried on the next resync, their health is kept to count the restarts
+	m.mu.Lock()
+	health, exists := m.health[namespace]
+	if !exists {
+		health = newWatcherHealth(namespace)
+		m.health[namespace] = health
+	}
+	m.mu.Unlock()
+
+	watcherCtx, cancel := context.WithCancel(leadCtx)
+	watcher := &namespaceWatcher{cancel: cancel}
+
+	backfilled, resourceVersion, err := m.backfill(watcherCtx, namespace)
+	if err != nil {
+		cancel()
+		health.failed(err)
+		m.logger.Errorf("Failed to backfill events: %v", err)
+		return 0, err
+	}
+
+	// The watcher is registered before its watches start, stopping it meanwhile waits for the start
+	watcher.consumers.Add(1)
+	defer watcher.consumers.Done()
+	if !m.register(leadCtx, namespace, removals, watcher, health) {
+		cancel()
+		return backfilled, nil
+	}
+
+	eventChan, err := m.k8sClient.WatchEvents(watcherCtx, namespace, resourceVersion, health)
+	if err != nil {
+		m.unregister(namespace, watcher)
+		cancel()
+		health.failed(err)
+		m.logger.Errorf("Failed to start watching events: %v", err)
+		return 0, err
+	}
+	watcher.consumers.Add(1)
+	go func() {
+		defer watcher.consumers.Done()
+		m.consume(eventChan)
+	}()
+
+	// Synthetic events are best effort, the namespace is still watched without them
+	lifecycleChan, err := m.k8sClient.WatchPodLifecycle(watcherCtx, namespace, m.pendingThreshold)
+	if err != nil {
+		m.logger.Errorf("Failed to start watching pod lifecycle in namespace %s: %v", namespace, err)
+	} else {
+		watcher.consumers.Add(1)
+		go func() {
+			defer watcher.consumers.Done()
+			m.consume(lifecycleChan)
+		}()
+	}
+
+	return backfilled, nil
+}
+
+// register adds the watcher unless the state changed since the namespace was chosen for watching
+func (m *WatchManager) register(leadCtx context.Context, namespace string, removals int, watcher *namespaceWatcher, health *watcherHealth) bool {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, watched := m.watchers[namespace]
+	if m.leadCtx == leadCtx && !watched && m.removals[namespace] == removals {
+		m.watchers[namespace] = watcher
+		return true
+	}
+	// The health created for this start is only kept while someone watches the namespace
+	if !watched && m.health[namespace] == health {
+		delete(m.health, namespace)
+	}
+	return false
+}
+
+// unregister removes the watcher if it wasn't replaced or stopped meanwhile
+func (m *WatchManager) unregister(namespace string, watcher *namespaceWatcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.watchers[namespace] == watcher {
+		delete(m.watchers, namespace)
+	}
+}
+
+// stopWatching must be called with ops held
+func (m *WatchManager) stopWatching(namespace string) {
+	m.mu.Lock()
+	watcher, exists := m.watchers[namespace]
+	delete(m.watchers, namespace)
+	delete(m.health, namespace)
+	m.mu.Unlock()
+
+	if exists {
+		watcher.stop()
+	}
+}
+
+// consume saves and publishes events until the channel is closed, replayed events were
+// already published and are only saved.
+// The watcher context may already be canceled while draining, so queueing doesn't depend on it.
+func (m *WatchManager) consume(eventChan chan Event) {
+	for event := range eventChan {
+		m.logger.Info("Received event in namespace", event.Namespace, "event name:", event.Name)
+		if err := m.ingester.Enqueue(context.Background(), event); err != nil {
+			m.logger.Errorf("Failed to queue event %s: %v", event.Name, err)
+		}
+		if !event.Replayed {
+			m.broker.Publish(event)
+		}
+	}
+}
+
+// backfill stores the events the API server still holds for the namespace,
+// they are upserted so backfilling the same namespace twice is harmless
+func (m *WatchManager) backfill(ctx context.Context, namespace string) (int, string, error) {
+	listed, resourceVersion, err := m.k8sClient.ListEvents(ctx, namespace)
+	if err != nil {
+		return 0, "", err
+	}
+
+	for start := 0; start < len(listed); start += ingestBatchSize {
+		end := min(start+ingestBatchSize, len(listed))
+		resolveOwners(m.k8sClient, listed[start:end])
+		if err := m.repository.SaveEvents(listed[start:end]); err != nil {
+			return 0, "", err
+		}
+	}
+
+	m.logger.Infof("Backfilled %d events in namespace %s", len(listed), namespace)
+	return len(listed), resourceVersion, nil
+}
+
+// manageNamespaces starts watching namespaces matching the selector as they are created
+// and stops watching deleted ones, their stored events are kept
+func (m *WatchManager) manageNamespaces(ctx context.Context, changes chan NamespaceChange) {
+	for change := range changes {
+		switch {
+		case change.Deleted:
+			namespaces, err := m.namespaceRepository.GetAllNamespaces()
+			if err != nil {
+				m.logger.Errorf("Failed to get watched namespaces: %v", err)
+				continue
+			}
+			if !slices.Contains(namespaces, change.Namespace) {
+				continue
+			}
+			m.logger.Infof("Namespace %s was deleted, removing it from the watch list", change.Namespace)
+			if err := m.Remove(change.Namespace); err != nil {
+				m.logger.Errorf("Failed to remove deleted namespace %s: %v", change.Namespace, err)
+			}
+		case change.Selected:
+			if m.IsWatched(change.Namespace) {
+				continue
+			}
+			m.logger.Infof("Namespace %s matches the auto-watch selector, adding it to the watch list", change.Namespace)
+			if _, err := m.Add(ctx, change.Namespace); err != nil {
+				m.logger.Errorf("Failed to auto-watch namespace %s: %v", change.Namespace, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// watcherRestartWarningThreshold is how long a watcher may keep reconnecting before it's reported
+const watcherRestartWarningThreshold = 2 * time.Minute
+
+type WatcherState string
+
+const (
+	WatcherStateRunning    WatcherState = "running"
+	WatcherStateRestarting WatcherState = "restarting"
+	WatcherStateFailed     WatcherState = "failed"
+	// WatcherStateNotRunning is reported for namespaces that aren't watched by this replica
+	WatcherStateNotRunning WatcherState = "not_running"
+	// WatcherStateStandby is reported by followers, the namespaces are watched by the leader
+	WatcherStateStandby WatcherState = "standby"
+)
+
+// WatcherStatus is reported per replica. Reconnects counts the watches closed by the API server,
+// which happens routinely, Restarts only counts the failed attempts to watch again.
+// Leader is set on followers, it's the replica watching the namespace.
+type WatcherStatus struct {
+	Namespace       string       `json:"namespace"`
+	State           WatcherState `json:"state"`
+	StartedAt       *time.Time   `json:"started_at,omitempty"`
+	LastEventTime   *time.Time   `json:"last_event_time,omitempty"`
+	EventsReceived  int64        `json:"events_received"`
+	Reconnects      int          `json:"reconnects"`
+	Restarts        int          `json:"restarts"`
+	LastError       string       `json:"last_error,omitempty"`
+	ResourceVersion string       `json:"resource_version,omitempty"`
+	RestartingSince *time.Time   `json:"restarting_since,omitempty"`
+	Warning         string       `json:"warning,omitempty"`
+	Leader          string       `json:"leader,omitempty"`
+}
+
+// WatchObserver receives the lifecycle of a single namespace watch from the kubernetes client
+type WatchObserver interface {
+	OnStarted(resourceVersion string)
+	OnEvent(resourceVersion string)
+	OnResourceVersion(resourceVersion string)
+	OnRestarting(err error)
+	OnError(err error)
+}
+
+type watcherHealth struct {
+	mu     sync.Mutex
+	status WatcherStatus
+}
+
+func newWatcherHealth(namespace string) *watcherHealth {
+	return &watcherHealth{
+		status: WatcherStatus{
+			Namespace: namespace,
+			State:     WatcherStateNotRunning,
+		},
+	}
+}
+
+func (h *watcherHealth) OnStarted(resourceVersion string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if h.status.StartedAt == nil {
+		h.status.StartedAt = &now
+	}
+	h.status.State = WatcherStateRunning
+	h.status.RestartingSince = nil
+	if resourceVersion != "" {
+		h.status.ResourceVersion = resourceVersion
+	}
+}
+
+func (h *watcherHealth) OnEvent(resourceVersion string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.status.LastEventTime = &now
+	h.status.EventsReceived++
+	h.status.ResourceVersion = resourceVersion
+}
+
+func (h *watcherHealth) OnResourceVersion(resourceVersion string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status.ResourceVersion = resourceVersion
+}
+
+// OnRestarting is called when the watch ended, a nil error is a clean close by the API server
+func (h *watcherHealth) OnRestarting(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.status.State != WatcherStateRestarting {
+		now := time.Now()
+		h.status.RestartingSince = &now
+	}
+	h.status.State = WatcherStateRestarting
+	if err == nil {
+		h.status.Reconnects++
+		return
+	}
+	h.status.Restarts++
+	h.status.LastError = err.Error()
+}
+
+// OnError is called for watch errors, while restarting they mean the watch couldn't be started again
+func (h *watcherHealth) OnError(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.status.State == WatcherStateRestarting {
+		h.status.Restarts++
+	}
+	h.status.LastError = err.Error()
+}
+
+func (h *watcherHealth) failed(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status.State = WatcherStateFailed
+	h.status.LastError = err.Error()
+}
+
+func (h *watcherHealth) Status() WatcherStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	status := h.status
+	if status.State == WatcherStateRestarting && status.RestartingSince != nil {
+		if restartingFor := time.Since(*status.RestartingSince); restartingFor > watcherRestartWarningThreshold {
+			status.Warning = fmt.Sprintf("watcher has been reconnecting for %s", restartingFor.Truncate(time.Second))
+		}
+	}
+	return status
+}
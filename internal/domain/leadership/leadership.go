@@ -0,0 +1,20 @@
+package leadership
+
+import "context"
+
+type Status struct {
+	Enabled  bool   `json:"enabled"`
+	Identity string `json:"identity"`
+	Leader   string `json:"leader"`
+	IsLeader bool   `json:"is_leader"`
+}
+
+// Elector decides which replica runs the singleton background work.
+// OnStartedLeading callbacks receive a context that is canceled when the leadership is lost,
+// callbacks must be registered before the application starts.
+type Elector interface {
+	Status() Status
+	IsLeader() bool
+	OnStartedLeading(fn func(ctx context.Context))
+	OnStoppedLeading(fn func())
+}
@@ -0,0 +1,11 @@
+package metrics
+
+import "errors"
+
+var ErrUnknownMetric = errors.New("unknown metric")
+
+// MetricsAll selects every historical metric of the target
+const MetricsAll = "all"
+
+// DefaultHistoryMetrics are returned when no metrics are selected
+var DefaultHistoryMetrics = []string{"cpu_usage", "memory_usage"}
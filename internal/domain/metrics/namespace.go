@@ -0,0 +1,51 @@
+package metrics
+
+import "errors"
+
+var ErrNotFound = errors.New("not found")
+
+// NamespaceSummary aggregates the pods of a namespace, CPU is in millicores and memory in MiB.
+// Requests and limits only count pods that aren't finished, like the quota does.
+// Usage is nil when no metrics source has it.
+type NamespaceSummary struct {
+	Namespace         string                 `json:"namespace"`
+	Pods              int                    `json:"pods"`
+	PodsByPhase       map[string]int         `json:"pods_by_phase"`
+	PodsWithoutLimits int                    `json:"pods_without_limits"`
+	CPUUsage          *int64                 `json:"cpu_usage"`
+	CPURequest        int64                  `json:"cpu_request"`
+	CPULimit          int64                  `json:"cpu_limit"`
+	MemoryUsage       *int64                 `json:"memory_usage"`
+	MemoryRequest     int64                  `json:"memory_request"`
+	MemoryLimit       int64                  `json:"memory_limit"`
+	Quotas            []ResourceQuotaSummary `json:"quotas"`
+	LimitRanges       []LimitRangeSummary    `json:"limit_ranges"`
+	// MaxQuotaUtilization is the highest utilization across all quota resources
+	MaxQuotaUtilization *float64 `json:"max_quota_utilization,omitempty"`
+}
+
+type ResourceQuotaSummary struct {
+	Name      string          `json:"name"`
+	Resources []QuotaResource `json:"resources"`
+}
+
+type QuotaResource struct {
+	Resource           string   `json:"resource"`
+	Hard               string   `json:"hard"`
+	Used               string   `json:"used"`
+	UtilizationPercent *float64 `json:"utilization_percent,omitempty"`
+}
+
+type LimitRangeSummary struct {
+	Name   string           `json:"name"`
+	Limits []LimitRangeItem `json:"limits"`
+}
+
+type LimitRangeItem struct {
+	Type                 string            `json:"type"`
+	Default              map[string]string `json:"default,omitempty"`
+	DefaultRequest       map[string]string `json:"default_request,omitempty"`
+	Min                  map[string]string `json:"min,omitempty"`
+	Max                  map[string]string `json:"max,omitempty"`
+	MaxLimitRequestRatio map[string]string `json:"max_limit_request_ratio,omitempty"`
+}
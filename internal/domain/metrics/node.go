@@ -1,5 +1,7 @@
 package metrics
 
+import "time"
+
 type NodeMetrics struct {
 	NodeName              string   `json:"name"`
 	CPUUsage              string   `json:"cpu_usage"`
@@ -11,3 +13,74 @@ type NodeMetrics struct {
 	Roles                 []string `json:"roles"`
 	Status                string   `json:"status"`
 }
+
+type Taint struct {
+	Key       string     `json:"key"`
+	Value     string     `json:"value,omitempty"`
+	Effect    string     `json:"effect"`
+	TimeAdded *time.Time `json:"time_added,omitempty"`
+}
+
+type NodeAddress struct {
+	Type    string `json:"type"`
+	Address string `json:"address"`
+}
+
+// NodeDetail is the full state of a node, capacity and allocatable keep the quantities as
+// reported by the kubelet so extended resources are included. Usage is in millicores and MiB,
+// it is nil when no metrics source has it.
+type NodeDetail struct {
+	Name                    string            `json:"name"`
+	Roles                   []string          `json:"roles"`
+	Status                  string            `json:"status"`
+	Unschedulable           bool              `json:"unschedulable"`
+	Conditions              []Condition       `json:"conditions"`
+	Taints                  []Taint           `json:"taints"`
+	Labels                  map[string]string `json:"labels"`
+	Addresses               []NodeAddress     `json:"addresses"`
+	Capacity                map[string]string `json:"capacity"`
+	Allocatable             map[string]string `json:"allocatable"`
+	CPUUsage                *int64            `json:"cpu_usage"`
+	MemoryUsage             *int64            `json:"memory_usage"`
+	KubeletVersion          string            `json:"kubelet_version"`
+	ContainerRuntimeVersion string            `json:"container_runtime_version"`
+	OSImage                 string            `json:"os_image"`
+	OperatingSystem         string            `json:"operating_system"`
+	Architecture            string            `json:"architecture"`
+	KernelVersion           string            `json:"kernel_version"`
+	CreatedAt               string            `json:"created_at"`
+	Pods                    []PodMetrics      `json:"pods"`
+}
+
+// ResourceAllocation compares what pods reserve on nodes with what the nodes can offer,
+// CPU is in millicores and memory in MiB. Usage is nil when no metrics source has it.
+type ResourceAllocation struct {
+	Allocatable     int64   `json:"allocatable"`
+	Requests        int64   `json:"requests"`
+	Limits          int64   `json:"limits"`
+	RequestsPercent float64 `json:"requests_percent"`
+	// OvercommitRatio is limits over allocatable, above 1 the pods can't all reach their limits
+	OvercommitRatio float64 `json:"overcommit_ratio"`
+	// Free is what the scheduler can still place, allocatable minus requests
+	Free         int64    `json:"free"`
+	Usage        *int64   `json:"usage,omitempty"`
+	UsagePercent *float64 `json:"usage_percent,omitempty"`
+}
+
+type NodeAllocation struct {
+	Name              string             `json:"name"`
+	Status            string             `json:"status"`
+	Unschedulable     bool               `json:"unschedulable"`
+	Pods              int                `json:"pods"`
+	PodCapacity       int64              `json:"pod_capacity"`
+	PodsWithoutLimits int                `json:"pods_without_limits"`
+	CPU               ResourceAllocation `json:"cpu"`
+	Memory            ResourceAllocation `json:"memory"`
+}
+
+// ClusterAllocation sums the nodes, the free headroom of the total only counts the nodes that
+// are ready and schedulable since nothing new can be placed on the others
+type ClusterAllocation struct {
+	Nodes []NodeAllocation `json:"nodes"`
+	Total NodeAllocation   `json:"total"`
+}
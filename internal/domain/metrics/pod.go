@@ -1,18 +1,43 @@
 package metrics
 
 type PodMetrics struct {
-	PodName            string   `json:"pod_name"`
-	Namespace          string   `json:"namespace"`
-	NodeName           string   `json:"node_name"`
-	CPUUsage           int64    `json:"cpu_usage"`
-	CPUUsagePercent    *float64 `json:"cpu_usage_percent"`
-	CPUUsageLimit      int64    `json:"cpu_usage_limit"`
-	CPUUsageRequest    int64    `json:"cpu_usage_request"`
-	MemoryUsage        int64    `json:"memory_usage"`
-	MemoryUsagePercent *float64 `json:"memory_usage_percent,omitempty"`
-	MemoryUsageLimit   int64    `json:"memory_usage_limit"`
-	MemoryUsageRequest int64    `json:"memory_usage_request"`
-	Status             string   `json:"status"`
-	StartTime          string   `json:"start_time"`
-	RestartCount       int32    `json:"restart_count"`
+	PodName            string             `json:"pod_name"`
+	Namespace          string             `json:"namespace"`
+	NodeName           string             `json:"node_name"`
+	CPUUsage           int64              `json:"cpu_usage"`
+	CPUUsagePercent    *float64           `json:"cpu_usage_percent"`
+	CPUUsageLimit      int64              `json:"cpu_usage_limit"`
+	CPUUsageRequest    int64              `json:"cpu_usage_request"`
+	MemoryUsage        int64              `json:"memory_usage"`
+	MemoryUsagePercent *float64           `json:"memory_usage_percent,omitempty"`
+	MemoryUsageLimit   int64              `json:"memory_usage_limit"`
+	MemoryUsageRequest int64              `json:"memory_usage_request"`
+	Status             string             `json:"status"`
+	StartTime          string             `json:"start_time"`
+	RestartCount       int32              `json:"restart_count"`
+	Containers         []ContainerMetrics `json:"containers"`
+}
+
+const (
+	ContainerTypeApp     = "container"
+	ContainerTypeInit    = "init"
+	ContainerTypeSidecar = "sidecar"
+)
+
+// ContainerMetrics uses the units of PodMetrics: millicores for CPU and MiB for memory,
+// a zero limit means the container is unlimited
+type ContainerMetrics struct {
+	Name                  string `json:"name"`
+	Type                  string `json:"type"`
+	CPUUsage              int64  `json:"cpu_usage"`
+	CPUUsageLimit         int64  `json:"cpu_usage_limit"`
+	CPUUsageRequest       int64  `json:"cpu_usage_request"`
+	MemoryUsage           int64  `json:"memory_usage"`
+	MemoryUsageLimit      int64  `json:"memory_usage_limit"`
+	MemoryUsageRequest    int64  `json:"memory_usage_request"`
+	RestartCount          int32  `json:"restart_count"`
+	Ready                 bool   `json:"ready"`
+	State                 string `json:"state"`
+	Reason                string `json:"reason,omitempty"`
+	LastTerminationReason string `json:"last_termination_reason,omitempty"`
 }
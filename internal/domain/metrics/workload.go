@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUnknownWorkloadKind = errors.New("kind must be one of deployment, statefulset, daemonset or job")
+
+const (
+	WorkloadKindDeployment  = "Deployment"
+	WorkloadKindStatefulSet = "StatefulSet"
+	WorkloadKindDaemonSet   = "DaemonSet"
+	WorkloadKindJob         = "Job"
+)
+
+const (
+	RolloutComplete    = "complete"
+	RolloutProgressing = "progressing"
+	RolloutPaused      = "paused"
+	RolloutFailed      = "failed"
+)
+
+type Condition struct {
+	Type               string     `json:"type"`
+	Status             string     `json:"status"`
+	Reason             string     `json:"reason,omitempty"`
+	Message            string     `json:"message,omitempty"`
+	LastTransitionTime *time.Time `json:"last_transition_time,omitempty"`
+}
+
+// Workload is a controller with the usage of the pods it owns, in the units of PodMetrics.
+// For a DaemonSet the replicas are the number of nodes it should run on, for a Job the
+// number of completions, or its parallelism when any successful pod completes it.
+type Workload struct {
+	Kind               string      `json:"kind"`
+	Name               string      `json:"name"`
+	Namespace          string      `json:"namespace"`
+	Replicas           int32       `json:"replicas"`
+	ReadyReplicas      int32       `json:"ready_replicas"`
+	AvailableReplicas  int32       `json:"available_replicas"`
+	UpdatedReplicas    int32       `json:"updated_replicas"`
+	RolloutStatus      string      `json:"rollout_status"`
+	RolloutMessage     string      `json:"rollout_message,omitempty"`
+	Images             []string    `json:"images"`
+	Conditions         []Condition `json:"conditions"`
+	Pods               int         `json:"pods"`
+	RestartCount       int32       `json:"restart_count"`
+	CPUUsage           *int64      `json:"cpu_usage"`
+	CPUUsageRequest    int64       `json:"cpu_usage_request"`
+	CPUUsageLimit      int64       `json:"cpu_usage_limit"`
+	MemoryUsage        *int64      `json:"memory_usage"`
+	MemoryUsageRequest int64       `json:"memory_usage_request"`
+	MemoryUsageLimit   int64       `json:"memory_usage_limit"`
+	CreatedAt          string      `json:"created_at"`
+	Job                *JobStatus  `json:"job,omitempty"`
+}
+
+// JobStatus is the progress of a Job, CronJob is set for the jobs it created
+type JobStatus struct {
+	Completions    *int32     `json:"completions,omitempty"`
+	Parallelism    int32      `json:"parallelism"`
+	Active         int32      `json:"active"`
+	Succeeded      int32      `json:"succeeded"`
+	Failed         int32      `json:"failed"`
+	Suspended      bool       `json:"suspended"`
+	CronJob        string     `json:"cron_job,omitempty"`
+	StartTime      *time.Time `json:"start_time,omitempty"`
+	CompletionTime *time.Time `json:"completion_time,omitempty"`
+}
+
+// WorkloadRevision is a ReplicaSet of a Deployment, Current is the one the Deployment rolls out
+type WorkloadRevision struct {
+	Revision      int64    `json:"revision"`
+	ReplicaSet    string   `json:"replica_set"`
+	Images        []string `json:"images"`
+	Replicas      int32    `json:"replicas"`
+	ReadyReplicas int32    `json:"ready_replicas"`
+	ChangeCause   string   `json:"change_cause,omitempty"`
+	CreatedAt     string   `json:"created_at"`
+	Current       bool     `json:"current"`
+}
+
+type WorkloadDetail struct {
+	Workload
+	Selector  string             `json:"selector"`
+	Strategy  string             `json:"strategy"`
+	Revisions []WorkloadRevision `json:"revisions"`
+	PodList   []PodMetrics       `json:"pod_list"`
+}
@@ -6,4 +6,5 @@ var Module = fx.Module("database",
 	fx.Provide(NewEventPGRepository),
 	fx.Provide(NewWatchedNamespacePGRepository),
 	fx.Provide(NewTelegramAlertPGRepository),
+	fx.Provide(NewEventRatePGRepository),
 )
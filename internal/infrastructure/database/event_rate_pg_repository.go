@@ -0,0 +1,68 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"main/internal/domain/anomalies"
+	"main/pkg"
+	"strings"
+	"time"
+)
+
+type EventRatePGRepo struct {
+	database pkg.Database
+	table    string
+}
+
+func NewEventRatePGRepository(database pkg.Database) anomalies.EventRateRepository {
+	return EventRatePGRepo{
+		database: database,
+		table:    "event_rate_buckets",
+	}
+}
+
+func (repo EventRatePGRepo) GetRateAggregates(query anomalies.RateQuery) ([]anomalies.RateAggregate, error) {
+	if len(query.Ranges) == 0 {
+		return []anomalies.RateAggregate{}, nil
+	}
+
+	args := []any{fmt.Sprintf("%d seconds", int64(query.Window.Seconds())), query.Origin}
+	ranges := make([]string, 0, len(query.Ranges))
+	for _, timeRange := range query.Ranges {
+		args = append(args, timeRange.Since, timeRange.Until)
+		ranges = append(ranges, fmt.Sprintf("(bucket >= $%d AND bucket < $%d)", len(args)-1, len(args)))
+	}
+	where := []string{"(" + strings.Join(ranges, " OR ") + ")"}
+	if len(query.Namespaces) > 0 {
+		args = append(args, query.Namespaces)
+		where = append(where, fmt.Sprintf("namespace = ANY($%d)", len(args)))
+	}
+
+	sqlQuery := `
+		SELECT namespace, reason, SUM(occurrences) AS total, SUM(occurrences * occurrences) AS total_squares
+		FROM (
+			SELECT namespace, reason, date_bin($1::interval, bucket, $2::timestamp) AS window_start, SUM(occurrences) AS occurrences
+			FROM ` + repo.table + whereClause(where) + `
+			GROUP BY 1, 2, 3
+		) windows
+		GROUP BY 1, 2
+	`
+
+	aggregates := make([]anomalies.RateAggregate, 0)
+	err := repo.database.Select(&aggregates, sqlQuery, args...)
+	if err != nil {
+		log.Printf("Query: %s, Args: %v", sqlQuery, args)
+		return nil, fmt.Errorf("failed to query event rates: %w", err)
+	}
+
+	return aggregates, nil
+}
+
+func (repo EventRatePGRepo) GetFirstBucket() (*time.Time, error) {
+	var first *time.Time
+	err := repo.database.Get(&first, `SELECT MIN(bucket) FROM `+repo.table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query first event rate bucket: %w", err)
+	}
+	return first, nil
+}
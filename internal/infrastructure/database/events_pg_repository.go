@@ -3,60 +3,204 @@ package database
 import (
 	"fmt"
 	"log"
+	"main/internal/config"
 	"main/internal/domain/events"
 	"main/pkg"
+	"strings"
+	"time"
 )
 
 func NewEventPGRepository(database pkg.Database) events.EventRepository {
 	return EventPGRepo{
-		database: database,
-		table:    "events",
+		database:       database,
+		table:          "events",
+		ratesTable:     "event_rate_buckets",
+		rateBucketSize: config.EventRateBucketSize,
 	}
 }
 
 type EventPGRepo struct {
-	database pkg.Database
-	table    string
+	database       pkg.Database
+	table          string
+	ratesTable     string
+	rateBucketSize time.Duration
 }
 
+const eventColumns = `id, namespace, name, reason, message, type,
+	involved_object, involved_kind, involved_name, involved_namespace,
+	involved_uid, involved_api_version, involved_field_path, owner_kind, owner_name,
+	first_timestamp, last_timestamp, count, source`
+
+const eventValues = `:id, :namespace, :name, :reason, :message, :type,
+	:involved_object, :involved_kind, :involved_name, :involved_namespace,
+	:involved_uid, :involved_api_version, :involved_field_path, :owner_kind, :owner_name,
+	:first_timestamp, :last_timestamp, :count, :source`
+
+// The owner can't always be resolved again when an event is updated, e.g. after its pod
+// was deleted, so an already known owner is kept
+const eventUpsertConflict = `
+	ON CONFLICT (id) DO UPDATE SET
+		reason = EXCLUDED.reason,
+		message = EXCLUDED.message,
+		type = EXCLUDED.type,
+		last_timestamp = EXCLUDED.last_timestamp,
+		count = EXCLUDED.count,
+		owner_kind = COALESCE(NULLIF(EXCLUDED.owner_kind, ''), events.owner_kind),
+		owner_name = COALESCE(NULLIF(EXCLUDED.owner_name, ''), events.owner_name)
+`
+
 func (repo EventPGRepo) SaveEvent(event events.Event) error {
+	return repo.SaveEvents([]events.Event{event})
+}
+
+// SaveEvents upserts the whole batch with a single multi-row insert
+func (repo EventPGRepo) SaveEvents(batch []events.Event) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	// A row can't be updated twice by the same statement, only the latest version of an event is kept
+	latest := make(map[string]int, len(batch))
+	unique := make([]events.Event, 0, len(batch))
+	for _, event := range batch {
+		// Filters and the pagination cursor use last_timestamp, it's never stored as zero
+		if event.LastTimestamp.IsZero() {
+			event.LastTimestamp = event.FirstTimestamp
+		}
+		if index, exists := latest[event.ID]; exists {
+			unique[index] = event
+			continue
+		}
+		latest[event.ID] = len(unique)
+		unique = append(unique, event)
+	}
+
+	tx, err := repo.database.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin events transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]string, 0, len(unique))
+	for _, event := range unique {
+		ids = append(ids, event.ID)
+	}
+	// Row locks don't cover events that aren't stored yet, two replicas inserting the same new event
+	// would both count all of its occurrences. The ids are locked until the commit instead, in key
+	// order so concurrent batches can't deadlock.
+	_, err = tx.Exec(`
+		SELECT pg_advisory_xact_lock(key)
+		FROM (SELECT DISTINCT hashtextextended(id, 0) AS key FROM unnest($1::text[]) AS id) AS keys
+		ORDER BY key
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to lock events: %w", err)
+	}
+	previous := make([]struct {
+		ID    string `db:"id"`
+		Count int32  `db:"count"`
+	}, 0)
+	err = tx.Select(&previous, `SELECT id, count FROM `+repo.table+` WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to query previous event counts: %w", err)
+	}
+	previousCounts := make(map[string]int32, len(previous))
+	for _, row := range previous {
+		previousCounts[row.ID] = row.Count
+	}
+
 	query := `
 		INSERT INTO ` + repo.table + ` (
-			id, namespace, name, reason, message, type, 
-			involved_object, first_timestamp, last_timestamp, count
+			` + eventColumns + `
 		)
 		VALUES (
-			:id, :namespace, :name, :reason, :message, :type,
-			:involved_object, :first_timestamp, :last_timestamp, :count
+			` + eventValues + `
 		)
-	`
-	_, err := repo.database.NamedExec(query, event)
-	if err != nil {
-		return err
+	` + eventUpsertConflict
+	if _, err := tx.NamedExec(query, unique); err != nil {
+		return fmt.Errorf("failed to save events batch: %w", err)
+	}
+
+	if rates := eventRateBuckets(unique, previousCounts, repo.rateBucketSize); len(rates) > 0 {
+		query := `
+			INSERT INTO ` + repo.ratesTable + ` (namespace, reason, bucket, occurrences)
+			VALUES (:namespace, :reason, :bucket, :occurrences)
+			ON CONFLICT (namespace, reason, bucket) DO UPDATE SET
+				occurrences = ` + repo.ratesTable + `.occurrences + EXCLUDED.occurrences
+		`
+		if _, err := tx.NamedExec(query, rates); err != nil {
+			return fmt.Errorf("failed to save event rates: %w", err)
+		}
 	}
-	return nil
+
+	return tx.Commit()
+}
+
+type eventRateBucket struct {
+	Namespace   string    `db:"namespace"`
+	Reason      string    `db:"reason"`
+	Bucket      time.Time `db:"bucket"`
+	Occurrences int64     `db:"occurrences"`
+}
+
+// eventRateBuckets attributes the increase of every event's count since it was last saved
+// to the bucket of its last occurrence
+func eventRateBuckets(batch []events.Event, previousCounts map[string]int32, bucketSize time.Duration) []eventRateBucket {
+	index := make(map[eventRateBucket]int)
+	rates := make([]eventRateBucket, 0)
+	for _, event := range batch {
+		count := max(event.Count, 1)
+		previous, exists := previousCounts[event.ID]
+		occurrences := int64(count)
+		if exists {
+			occurrences = int64(count - max(previous, 1))
+		}
+		if occurrences <= 0 {
+			continue
+		}
+
+		key := eventRateBucket{
+			Namespace: event.Namespace,
+			Reason:    event.Reason,
+			Bucket:    event.LastTimestamp.UTC().Truncate(bucketSize),
+		}
+		if i, ok := index[key]; ok {
+			rates[i].Occurrences += occurrences
+			continue
+		}
+		index[key] = len(rates)
+		key.Occurrences = occurrences
+		rates = append(rates, key)
+	}
+	return rates
 }
 
-func (repo EventPGRepo) GetEvents(namespace, eventType string, limit int) ([]events.Event, error) {
-	// Проверка limit
-	if limit <= 0 {
-		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+func (repo EventPGRepo) ListEvents(filter events.EventFilter) ([]events.Event, error) {
+	if filter.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", filter.Limit)
 	}
 
-	// Формирование запроса
-	query := `SELECT * FROM ` + repo.table + ` WHERE namespace = $1`
-	args := []any{namespace}
+	where, args := eventFilterConditions(filter)
 
-	// Добавление фильтра по type если указан
-	if eventType != "" {
-		query += ` AND type = $2`
-		args = append(args, eventType)
+	if filter.Cursor != nil {
+		comparison := "<"
+		if filter.Order == events.SortOrderAsc {
+			comparison = ">"
+		}
+		where = append(where, fmt.Sprintf("(last_timestamp, id) %s ($%d, $%d)", comparison, len(args)+1, len(args)+2))
+		args = append(args, filter.Cursor.LastTimestamp, filter.Cursor.ID)
 	}
 
-	query += ` ORDER BY last_timestamp DESC LIMIT $` + fmt.Sprintf("%d", len(args)+1)
-	args = append(args, limit)
+	direction := "DESC"
+	if filter.Order == events.SortOrderAsc {
+		direction = "ASC"
+	}
+
+	query := `SELECT * FROM ` + repo.table + whereClause(where) +
+		` ORDER BY last_timestamp ` + direction + `, id ` + direction +
+		` LIMIT $` + fmt.Sprintf("%d", len(args)+1)
+	args = append(args, filter.Limit)
 
-	// Выполнение запроса
 	events := make([]events.Event, 0)
 	err := repo.database.Select(&events, query, args...)
 	if err != nil {
@@ -67,6 +211,213 @@ func (repo EventPGRepo) GetEvents(namespace, eventType string, limit int) ([]eve
 	return events, nil
 }
 
+func (repo EventPGRepo) CountEvents(filter events.EventFilter) (int, error) {
+	where, args := eventFilterConditions(filter)
+	query := `SELECT COUNT(*) FROM ` + repo.table + whereClause(where)
+
+	var total int
+	err := repo.database.Get(&total, query, args...)
+	if err != nil {
+		log.Printf("Query: %s, Args: %v", query, args)
+		return 0, fmt.Errorf("failed to count events: %w", err)
+	}
+
+	return total, nil
+}
+
+// exportFetchSize is the number of rows fetched from the server side cursor at once
+const exportFetchSize = 500
+
+// ExportEvents streams every event matching the filter to fn using a server side cursor,
+// so the result set is never loaded into memory at once.
+func (repo EventPGRepo) ExportEvents(filter events.EventFilter, fn func(events.Event) error) error {
+	where, args := eventFilterConditions(filter)
+
+	direction := "DESC"
+	if filter.Order == events.SortOrderAsc {
+		direction = "ASC"
+	}
+
+	query := `SELECT * FROM ` + repo.table + whereClause(where) +
+		` ORDER BY last_timestamp ` + direction + `, id ` + direction
+	if filter.Limit > 0 {
+		query += ` LIMIT $` + fmt.Sprintf("%d", len(args)+1)
+		args = append(args, filter.Limit)
+	}
+
+	tx, err := repo.database.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin export transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DECLARE events_export NO SCROLL CURSOR FOR `+query, args...); err != nil {
+		log.Printf("Query: %s, Args: %v", query, args)
+		return fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	for {
+		batch := make([]events.Event, 0, exportFetchSize)
+		if err := tx.Select(&batch, fmt.Sprintf(`FETCH FORWARD %d FROM events_export`, exportFetchSize)); err != nil {
+			return fmt.Errorf("failed to fetch exported events: %w", err)
+		}
+
+		for _, event := range batch {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportFetchSize {
+			break
+		}
+	}
+
+	return tx.Commit()
+}
+
+var statsGroupColumns = map[events.StatsGroupBy]string{
+	events.StatsGroupByReason:    "reason",
+	events.StatsGroupByType:      "type",
+	events.StatsGroupByNamespace: "namespace",
+	events.StatsGroupByKind:      "involved_kind",
+	events.StatsGroupBySource:    "source",
+}
+
+func (repo EventPGRepo) CountEventsByBucket(query events.EventStatsQuery) ([]events.EventBucketCount, error) {
+	groupColumn, ok := statsGroupColumns[query.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported group by %q", query.GroupBy)
+	}
+	if query.Filter.Since == nil || query.Filter.Until == nil {
+		return nil, fmt.Errorf("stats require both since and until")
+	}
+
+	where, args := eventFilterConditions(query.Filter)
+	intervalArg := len(args) + 1
+	sinceArg := len(args) + 2
+	untilArg := len(args) + 3
+	args = append(args, fmt.Sprintf("%d seconds", int64(query.Interval.Seconds())), *query.Filter.Since, *query.Filter.Until)
+
+	// Buckets are aligned to the interval with date_bin, every key gets a row for
+	// every bucket of the range so the client doesn't have to fill the gaps
+	sqlQuery := fmt.Sprintf(`
+		WITH buckets AS (
+			SELECT generate_series(
+				date_bin($%[1]d::interval, $%[2]d::timestamp, date_trunc('day', $%[2]d::timestamp)),
+				$%[3]d::timestamp,
+				$%[1]d::interval
+			) AS bucket
+		),
+		counts AS (
+			SELECT
+				date_bin($%[1]d::interval, last_timestamp, date_trunc('day', $%[2]d::timestamp)) AS bucket,
+				%[4]s AS key,
+				COUNT(*) AS count
+			FROM `+repo.table+`%[5]s
+			GROUP BY 1, 2
+		),
+		keys AS (
+			SELECT DISTINCT key FROM counts
+		)
+		SELECT b.bucket, k.key, COALESCE(c.count, 0) AS count
+		FROM buckets b
+		LEFT JOIN keys k ON TRUE
+		LEFT JOIN counts c ON c.bucket = b.bucket AND c.key = k.key
+		ORDER BY k.key, b.bucket
+	`, intervalArg, sinceArg, untilArg, groupColumn, whereClause(where))
+
+	rows := make([]events.EventBucketCount, 0)
+	err := repo.database.Select(&rows, sqlQuery, args...)
+	if err != nil {
+		log.Printf("Query: %s, Args: %v", sqlQuery, args)
+		return nil, fmt.Errorf("failed to query event stats: %w", err)
+	}
+
+	return rows, nil
+}
+
+func (repo EventPGRepo) GetTopInvolvedObjects(filter events.EventFilter, limit int) ([]events.InvolvedObjectCount, error) {
+	where, args := eventFilterConditions(filter)
+	query := `
+		SELECT namespace, involved_object, COUNT(*) AS events, COALESCE(SUM(count), 0) AS occurrences
+		FROM ` + repo.table + whereClause(where) + `
+		GROUP BY namespace, involved_object
+		ORDER BY events DESC, occurrences DESC
+		LIMIT $` + fmt.Sprintf("%d", len(args)+1)
+	args = append(args, limit)
+
+	objects := make([]events.InvolvedObjectCount, 0)
+	err := repo.database.Select(&objects, query, args...)
+	if err != nil {
+		log.Printf("Query: %s, Args: %v", query, args)
+		return nil, fmt.Errorf("failed to query top involved objects: %w", err)
+	}
+
+	return objects, nil
+}
+
+// eventFilterConditions builds the WHERE conditions shared by every event query,
+// the cursor is not included since it only applies to a single page.
+func eventFilterConditions(filter events.EventFilter) ([]string, []any) {
+	where := make([]string, 0)
+	args := make([]any, 0)
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(condition, len(args)))
+	}
+
+	if len(filter.Namespaces) > 0 {
+		addCondition("namespace = ANY($%d)", filter.Namespaces)
+	}
+	if filter.Type != "" {
+		addCondition("type = $%d", filter.Type)
+	}
+	if filter.Reason != "" {
+		addCondition("reason = $%d", filter.Reason)
+	}
+	if filter.IncludeOwned && filter.InvolvedKind != "" && filter.InvolvedName != "" {
+		args = append(args, filter.InvolvedKind, filter.InvolvedName)
+		where = append(where, fmt.Sprintf(
+			"((involved_kind = $%[1]d AND involved_name = $%[2]d) OR (owner_kind = $%[1]d AND owner_name = $%[2]d))",
+			len(args)-1, len(args),
+		))
+	} else {
+		if filter.InvolvedKind != "" {
+			addCondition("involved_kind = $%d", filter.InvolvedKind)
+		}
+		if filter.InvolvedName != "" {
+			addCondition("involved_name = $%d", filter.InvolvedName)
+		}
+	}
+	// Kinds are compared case-insensitively so owner_kind=deployment works as well
+	if filter.OwnerKind != "" {
+		addCondition("lower(owner_kind) = lower($%d)", filter.OwnerKind)
+	}
+	if filter.OwnerName != "" {
+		addCondition("owner_name = $%d", filter.OwnerName)
+	}
+	if filter.Source != "" {
+		addCondition("source = $%d", filter.Source)
+	}
+	if filter.Since != nil {
+		addCondition("last_timestamp >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		addCondition("last_timestamp <= $%d", *filter.Until)
+	}
+
+	return where, args
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(conditions, " AND ")
+}
+
 type WatchedNamespacePGRepo struct {
 	database pkg.Database
 	table    string
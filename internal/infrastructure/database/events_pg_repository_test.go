@@ -0,0 +1,141 @@
+package database
+
+import (
+	"main/internal/domain/events"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEventFilterConditions(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter events.EventFilter
+		where  []string
+		args   []any
+	}{
+		{
+			name:   "all namespaces without filters",
+			filter: events.EventFilter{},
+			where:  []string{},
+			args:   []any{},
+		},
+		{
+			name:   "namespaces",
+			filter: events.EventFilter{Namespaces: []string{"default", "kube-system"}},
+			where:  []string{"namespace = ANY($1)"},
+			args:   []any{[]string{"default", "kube-system"}},
+		},
+		{
+			name:   "all namespaces with type and reason",
+			filter: events.EventFilter{Type: "Warning", Reason: "BackOff"},
+			where:  []string{"type = $1", "reason = $2"},
+			args:   []any{"Warning", "BackOff"},
+		},
+		{
+			name:   "involved object",
+			filter: events.EventFilter{Namespaces: []string{"default"}, InvolvedKind: "Pod", InvolvedName: "api-0"},
+			where:  []string{"namespace = ANY($1)", "involved_kind = $2", "involved_name = $3"},
+			args:   []any{[]string{"default"}, "Pod", "api-0"},
+		},
+		{
+			name:   "involved kind only",
+			filter: events.EventFilter{InvolvedKind: "Node"},
+			where:  []string{"involved_kind = $1"},
+			args:   []any{"Node"},
+		},
+		{
+			name:   "involved object including owned objects",
+			filter: events.EventFilter{Namespaces: []string{"default"}, InvolvedKind: "Deployment", InvolvedName: "api", IncludeOwned: true},
+			where: []string{
+				"namespace = ANY($1)",
+				"((involved_kind = $2 AND involved_name = $3) OR (owner_kind = $2 AND owner_name = $3))",
+			},
+			args: []any{[]string{"default"}, "Deployment", "api"},
+		},
+		{
+			name:   "including owned objects needs kind and name",
+			filter: events.EventFilter{InvolvedKind: "Deployment", IncludeOwned: true},
+			where:  []string{"involved_kind = $1"},
+			args:   []any{"Deployment"},
+		},
+		{
+			name:   "owner",
+			filter: events.EventFilter{OwnerKind: "deployment", OwnerName: "api"},
+			where:  []string{"lower(owner_kind) = lower($1)", "owner_name = $2"},
+			args:   []any{"deployment", "api"},
+		},
+		{
+			name:   "source and time range",
+			filter: events.EventFilter{Source: events.EventSourceSynthetic, Since: &since, Until: &until},
+			where:  []string{"source = $1", "last_timestamp >= $2", "last_timestamp <= $3"},
+			args:   []any{events.EventSourceSynthetic, since, until},
+		},
+		{
+			name: "every filter",
+			filter: events.EventFilter{
+				Namespaces:   []string{"default"},
+				Type:         "Warning",
+				Reason:       "BackOff",
+				InvolvedKind: "Pod",
+				InvolvedName: "api-0",
+				OwnerKind:    "Deployment",
+				OwnerName:    "api",
+				Source:       events.EventSourceKubernetes,
+				Since:        &since,
+				Until:        &until,
+				Order:        events.SortOrderAsc,
+				Limit:        50,
+				Cursor:       &events.EventCursor{LastTimestamp: since, ID: "id"},
+			},
+			where: []string{
+				"namespace = ANY($1)",
+				"type = $2",
+				"reason = $3",
+				"involved_kind = $4",
+				"involved_name = $5",
+				"lower(owner_kind) = lower($6)",
+				"owner_name = $7",
+				"source = $8",
+				"last_timestamp >= $9",
+				"last_timestamp <= $10",
+			},
+			args: []any{[]string{"default"}, "Warning", "BackOff", "Pod", "api-0", "Deployment", "api", events.EventSourceKubernetes, since, until},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			where, args := eventFilterConditions(test.filter)
+			if !reflect.DeepEqual(where, test.where) {
+				t.Errorf("where = %q, want %q", where, test.where)
+			}
+			if !reflect.DeepEqual(args, test.args) {
+				t.Errorf("args = %v, want %v", args, test.args)
+			}
+		})
+	}
+}
+
+func TestWhereClause(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []string
+		want       string
+	}{
+		{name: "no conditions", conditions: []string{}, want: ""},
+		{name: "single condition", conditions: []string{"type = $1"}, want: " WHERE type = $1"},
+		{name: "several conditions", conditions: []string{"type = $1", "reason = $2"}, want: " WHERE type = $1 AND reason = $2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := whereClause(test.conditions); got != test.want {
+				t.Errorf("whereClause() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package kubernetes
 
 import (
+	"main/internal/config"
 	"main/internal/domain/events"
+	"main/internal/domain/leadership"
 	"main/internal/infrastructure/prometheus"
 	"main/pkg"
 	"os"
@@ -20,11 +22,20 @@ type KubernetesClient struct {
 	logger           pkg.Logger
 	metricsClient    *versioned.Clientset
 	prometheusClient prometheus.PrometheusClient
+	metrics          metricsSource
+	owners           *ownerResolver
+	queries          *querySet
+	nodeInstances    *nodeInstanceResolver
 }
 
-var Module = fx.Module("kubernetes", fx.Provide(NewKubernetesClient), fx.Provide(func(kc *KubernetesClient) events.EventsKubernetesClient { return kc }))
+var Module = fx.Module("kubernetes",
+	fx.Provide(NewKubernetesClient),
+	fx.Provide(func(kc *KubernetesClient) events.EventsKubernetesClient { return kc }),
+	fx.Provide(NewLeaderElector),
+	fx.Provide(func(le *LeaderElector) leadership.Elector { return le }),
+)
 
-func NewKubernetesClient(logger pkg.Logger, prometheusClient prometheus.PrometheusClient) (*KubernetesClient, error) {
+func NewKubernetesClient(logger pkg.Logger, env config.Env, prometheusClient prometheus.PrometheusClient) (*KubernetesClient, error) {
 	var config *rest.Config
 	var err error
 
@@ -54,5 +65,27 @@ func NewKubernetesClient(logger pkg.Logger, prometheusClient prometheus.Promethe
 		return nil, err
 	}
 
-	return &KubernetesClient{clientset: clientset, metricsClient: metricsClient, logger: logger, prometheusClient: prometheusClient}, nil
+	queries, err := loadQuerySet(env.PrometheusQueryPreset, env.PrometheusQueryFile)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	nodeInstances := newNodeInstanceResolver(clientset, prometheusClient, queries)
+
+	source, err := newMetricsSource(env.MetricsSource, logger, prometheusClient, queries, nodeInstances, metricsClient)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return &KubernetesClient{
+		clientset:        clientset,
+		metricsClient:    metricsClient,
+		logger:           logger,
+		prometheusClient: prometheusClient,
+		metrics:          source,
+		owners:           newOwnerResolver(clientset),
+		queries:          queries,
+		nodeInstances:    nodeInstances,
+	}, nil
 }
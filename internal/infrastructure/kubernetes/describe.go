@@ -0,0 +1,181 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/events"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// ListObjectEvents returns the events the API server holds for a single object,
+// cluster-scoped objects are looked up in every namespace
+func (c KubernetesClient) ListObjectEvents(ctx context.Context, object events.InvolvedObject) ([]events.Event, error) {
+	selector := fields.Set{
+		"involvedObject.kind": object.Kind,
+		"involvedObject.name": object.Name,
+	}.AsSelector().String()
+
+	namespace := object.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceAll
+	}
+	list, err := c.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]events.Event, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, toDomainEvent(&list.Items[i]))
+	}
+	c.ResolveOwners(ctx, result)
+	return result, nil
+}
+
+func (c KubernetesClient) DescribePod(ctx context.Context, namespace string, name string) (*events.ObjectDescription, error) {
+	pod, err := c.clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: pod %s/%s", events.ErrObjectNotFound, namespace, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	description := &events.ObjectDescription{
+		Object:     podInvolvedObject(pod, ""),
+		Status:     podDisplayStatus(pod),
+		Reason:     pod.Status.Reason,
+		Message:    pod.Status.Message,
+		Conditions: make([]events.ObjectCondition, 0, len(pod.Status.Conditions)),
+		Containers: make([]events.ContainerState, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses)),
+		Events:     make([]events.Event, 0),
+	}
+	for _, condition := range pod.Status.Conditions {
+		description.Conditions = append(description.Conditions, objectCondition(
+			string(condition.Type), string(condition.Status), condition.Reason, condition.Message, condition.LastTransitionTime,
+		))
+	}
+	for _, status := range pod.Status.InitContainerStatuses {
+		description.Containers = append(description.Containers, containerState(status, true))
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		description.Containers = append(description.Containers, containerState(status, false))
+	}
+
+	return description, nil
+}
+
+func (c KubernetesClient) DescribeNode(ctx context.Context, name string) (*events.ObjectDescription, error) {
+	node, err := c.clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: node %s", events.ErrObjectNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	description := &events.ObjectDescription{
+		Object: events.InvolvedObject{
+			Kind:       "Node",
+			Name:       node.Name,
+			UID:        string(node.UID),
+			APIVersion: "v1",
+		},
+		Status:     getNodeStatus(node),
+		Conditions: make([]events.ObjectCondition, 0, len(node.Status.Conditions)),
+		Events:     make([]events.Event, 0),
+	}
+	if node.Spec.Unschedulable {
+		description.Status += ",SchedulingDisabled"
+	}
+	for _, condition := range node.Status.Conditions {
+		description.Conditions = append(description.Conditions, objectCondition(
+			string(condition.Type), string(condition.Status), condition.Reason, condition.Message, condition.LastTransitionTime,
+		))
+	}
+
+	return description, nil
+}
+
+func objectCondition(conditionType, status, reason, message string, lastTransition metav1.Time) events.ObjectCondition {
+	condition := events.ObjectCondition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+	if !lastTransition.IsZero() {
+		transition := lastTransition.Time
+		condition.LastTransitionTime = &transition
+	}
+	return condition
+}
+
+func containerState(status corev1.ContainerStatus, init bool) events.ContainerState {
+	state := events.ContainerState{
+		Name:         status.Name,
+		Init:         init,
+		Image:        status.Image,
+		Ready:        status.Ready,
+		RestartCount: status.RestartCount,
+	}
+
+	switch {
+	case status.State.Waiting != nil:
+		state.State = "waiting"
+		state.Reason = status.State.Waiting.Reason
+		state.Message = status.State.Waiting.Message
+	case status.State.Terminated != nil:
+		state.State = "terminated"
+		state.Reason = status.State.Terminated.Reason
+		state.Message = status.State.Terminated.Message
+	case status.State.Running != nil:
+		state.State = "running"
+	default:
+		state.State = "unknown"
+	}
+
+	if terminated := status.LastTerminationState.Terminated; terminated != nil {
+		state.LastTermination = fmt.Sprintf("%s (exit code %d)", terminated.Reason, terminated.ExitCode)
+	}
+	return state
+}
+
+// podDisplayStatus follows the STATUS column of kubectl get pods, so a failing pod
+// shows CrashLoopBackOff or ImagePullBackOff instead of its phase
+func podDisplayStatus(pod *corev1.Pod) string {
+	if pod.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+
+	for i, status := range pod.Status.InitContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode == 0 {
+			continue
+		}
+		if reason := containerWaitingReason(&status); reason != "" && reason != "PodInitializing" {
+			return "Init:" + reason
+		}
+		if terminated := status.State.Terminated; terminated != nil {
+			return "Init:" + terminated.Reason
+		}
+		return fmt.Sprintf("Init:%d/%d", i, len(pod.Spec.InitContainers))
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if reason := containerWaitingReason(&status); reason != "" && reason != "ContainerCreating" {
+			return reason
+		}
+		if terminated := status.State.Terminated; terminated != nil && terminated.Reason != "" && pod.Status.Phase != corev1.PodSucceeded {
+			return terminated.Reason
+		}
+	}
+
+	return string(pod.Status.Phase)
+}
@@ -2,21 +2,45 @@ package kubernetes
 
 import (
 	"context"
+	"fmt"
 	"main/internal/domain/events"
+	"net/http"
+	"strconv"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
-func (c KubernetesClient) WatchEvents(ctx context.Context, namespace string) (chan events.Event, error) {
+// ListEvents returns the events the API server still holds for the namespace
+// together with the resourceVersion a watch should continue from.
+// Owners aren't resolved here, see ResolveOwners.
+func (c KubernetesClient) ListEvents(ctx context.Context, namespace string) ([]events.Event, string, error) {
+	list, err := c.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]events.Event, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, toDomainEvent(&list.Items[i]))
+	}
+	return result, list.ResourceVersion, nil
+}
+
+// WatchEvents watches the namespace starting after resourceVersion, an empty one starts from now.
+// The watch is restarted from the last received resourceVersion when the server closes it,
+// observer is notified about every state change of the watch.
+func (c KubernetesClient) WatchEvents(ctx context.Context, namespace string, resourceVersion string, observer events.WatchObserver) (chan events.Event, error) {
 	c.logger.Info("Starting event watch in namespace", namespace)
-	eventChan := make(chan events.Event)
-	watcher, err := c.clientset.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{})
+	eventChan := make(chan events.Event, 100)
+	watcher, err := c.watchEvents(ctx, namespace, resourceVersion)
 	if err != nil {
 		c.logger.Errorf("Failed to create watcher: %v", err)
 		return nil, err
 	}
+	observer.OnStarted(resourceVersion)
 
 	go func() {
 		defer close(eventChan)
@@ -25,13 +49,52 @@ func (c KubernetesClient) WatchEvents(ctx context.Context, namespace string) (ch
 			case watchEvent, ok := <-watcher.ResultChan():
 				if !ok {
 					c.logger.Info("Watcher channel closed, restarting...")
-					time.Sleep(1 * time.Second)
-					watcher, err = c.clientset.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{})
+					observer.OnRestarting(nil)
+					if !sleepContext(ctx, 1*time.Second) {
+						return
+					}
+					watcher, err = c.watchEvents(ctx, namespace, resourceVersion)
 					if err != nil {
 						c.logger.Errorf("Failed to restart watcher: %v", err)
-						time.Sleep(5 * time.Second)
+						observer.OnError(err)
+						if !sleepContext(ctx, 5*time.Second) {
+							return
+						}
+						watcher = watch.NewEmptyWatch()
+						continue
+					}
+					observer.OnStarted(resourceVersion)
+					continue
+				}
+				if watchEvent.Type == watch.Error {
+					status, ok := watchEvent.Object.(*metav1.Status)
+					if ok && status.Code == http.StatusGone {
+						// The resourceVersion is too old, list again so nothing is missed in between
+						c.logger.Infof("Resource version %s expired in namespace %s, relisting", resourceVersion, namespace)
+						observer.OnError(fmt.Errorf("resource version %s expired", resourceVersion))
+						list, err := c.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+						if err != nil {
+							c.logger.Errorf("Failed to relist events: %v", err)
+							observer.OnError(err)
+							resourceVersion = ""
+						} else {
+							// Only what changed after the expired resourceVersion reaches the live subscribers again
+							expired := resourceVersion
+							resourceVersion = list.ResourceVersion
+							for i := range list.Items {
+								event := toDomainEvent(&list.Items[i])
+								event.Replayed = !newerResourceVersion(list.Items[i].ResourceVersion, expired)
+								select {
+								case eventChan <- event:
+								case <-ctx.Done():
+								}
+							}
+						}
+						watcher.Stop()
 						continue
 					}
+					c.logger.Errorf("Watch error in namespace %s: %v", namespace, watchEvent.Object)
+					observer.OnError(fmt.Errorf("watch error: %v", watchEvent.Object))
 					continue
 				}
 				c.logger.Info("Received event from watcher")
@@ -40,23 +103,20 @@ func (c KubernetesClient) WatchEvents(ctx context.Context, namespace string) (ch
 					c.logger.Errorf("Unexpected type for event: %T", watchEvent.Object)
 					continue
 				}
-				involvedObject := event.InvolvedObject.Kind + "/" + event.InvolvedObject.Name
-				domainEvent := events.Event{
-					Namespace:      event.Namespace,
-					Name:           event.Name,
-					Reason:         event.Reason,
-					Message:        event.Message,
-					Type:           event.Type,
-					FirstTimestamp: event.FirstTimestamp.Time,
-					InvolvedObject: involvedObject,
-					Count:          event.Count,
-					ID:             string(event.UID),
-				}
-				if event.DeletionTimestamp != nil {
-					domainEvent.LastTimestamp = event.DeletionTimestamp.Time
+				resourceVersion = event.ResourceVersion
+				// Bookmarks only move the resourceVersion, deletions are the API server expiring
+				// old events and the stored history is kept
+				if watchEvent.Type == watch.Bookmark || watchEvent.Type == watch.Deleted {
+					observer.OnResourceVersion(resourceVersion)
+					continue
 				}
+				observer.OnEvent(resourceVersion)
+				domainEvent := toDomainEvent(event)
 				c.logger.Info("Sending event to channel", domainEvent.Name)
-				eventChan <- domainEvent
+				select {
+				case eventChan <- domainEvent:
+				case <-ctx.Done():
+				}
 			case <-ctx.Done():
 				c.logger.Info("Context canceled, stopping watcher")
 				watcher.Stop()
@@ -67,3 +127,82 @@ func (c KubernetesClient) WatchEvents(ctx context.Context, namespace string) (ch
 
 	return eventChan, nil
 }
+
+func (c KubernetesClient) watchEvents(ctx context.Context, namespace string, resourceVersion string) (watch.Interface, error) {
+	return c.clientset.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{
+		ResourceVersion:     resourceVersion,
+		AllowWatchBookmarks: true,
+	})
+}
+
+// newerResourceVersion reports whether version comes after since. Resource versions are
+// meant to be opaque, anything that isn't a number is treated as already seen.
+func newerResourceVersion(version string, since string) bool {
+	current, err := strconv.ParseUint(version, 10, 64)
+	if err != nil {
+		return false
+	}
+	previous, err := strconv.ParseUint(since, 10, 64)
+	if err != nil {
+		return false
+	}
+	return current > previous
+}
+
+// sleepContext waits for the duration and reports false if the context was canceled meanwhile
+func sleepContext(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func toDomainEvent(event *corev1.Event) events.Event {
+	firstTimestamp := event.FirstTimestamp.Time
+	if firstTimestamp.IsZero() {
+		firstTimestamp = event.EventTime.Time
+	}
+	if firstTimestamp.IsZero() {
+		firstTimestamp = event.CreationTimestamp.Time
+	}
+
+	// Events created through events.k8s.io only fill the series
+	lastTimestamp := event.LastTimestamp.Time
+	if lastTimestamp.IsZero() && event.Series != nil {
+		lastTimestamp = event.Series.LastObservedTime.Time
+	}
+	if lastTimestamp.IsZero() {
+		lastTimestamp = firstTimestamp
+	}
+
+	count := event.Count
+	if count == 0 && event.Series != nil {
+		count = event.Series.Count
+	}
+
+	domainEvent := events.Event{
+		Namespace:      event.Namespace,
+		Name:           event.Name,
+		Reason:         event.Reason,
+		Message:        event.Message,
+		Type:           event.Type,
+		FirstTimestamp: firstTimestamp,
+		LastTimestamp:  lastTimestamp,
+		Count:          count,
+		ID:             string(event.UID),
+		Source:         events.EventSourceKubernetes,
+	}
+	domainEvent.SetInvolvedObject(events.InvolvedObject{
+		Kind:       event.InvolvedObject.Kind,
+		Name:       event.InvolvedObject.Name,
+		Namespace:  event.InvolvedObject.Namespace,
+		UID:        string(event.InvolvedObject.UID),
+		APIVersion: event.InvolvedObject.APIVersion,
+		FieldPath:  event.InvolvedObject.FieldPath,
+	})
+	return domainEvent
+}
@@ -0,0 +1,103 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/metrics"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"golang.org/x/sync/errgroup"
+)
+
+type MetricPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// HistoricalMetrics maps the selected metric names to their points
+type HistoricalMetrics map[string][]MetricPoint
+
+func (c *KubernetesClient) GetPodHistoricalMetrics(ctx context.Context, namespace, podName string, names []string, start, end time.Time, step time.Duration) (HistoricalMetrics, error) {
+	return c.getHistoricalMetrics(ctx, queryGroupPod, queryData{Namespace: namespace, Pod: podName}, names, start, end, step)
+}
+
+func (c *KubernetesClient) GetNodeHistoricalMetrics(ctx context.Context, nodeName string, names []string, start, end time.Time, step time.Duration) (HistoricalMetrics, error) {
+	instance, err := c.nodeInstances.instance(ctx, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return c.getHistoricalMetrics(ctx, queryGroupNode, queryData{Node: instance}, names, start, end, step)
+}
+
+func (c *KubernetesClient) getHistoricalMetrics(ctx context.Context, group string, data queryData, names []string, start, end time.Time, step time.Duration) (HistoricalMetrics, error) {
+	names, err := c.selectHistoryMetrics(group, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	result := make(HistoricalMetrics, len(names))
+
+	queries, queriesCtx := errgroup.WithContext(ctx)
+	queries.SetLimit(maxConcurrentQueries)
+	for _, name := range names {
+		queries.Go(func() error {
+			query, err := c.queries.render(group, name, data)
+			if err != nil {
+				return fmt.Errorf("failed to render %s query: %w", name, err)
+			}
+			value, err := c.prometheusClient.GetMetricHistoryContext(queriesCtx, query, start, end, step)
+			if err != nil {
+				return fmt.Errorf("failed to get %s history: %w", name, err)
+			}
+
+			points := make([]MetricPoint, 0)
+			if matrix, ok := value.(model.Matrix); ok {
+				for _, series := range matrix {
+					for _, point := range series.Values {
+						points = append(points, MetricPoint{
+							Timestamp: point.Timestamp.Time(),
+							Value:     float64(point.Value),
+						})
+					}
+				}
+			}
+
+			mu.Lock()
+			result[name] = points
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := queries.Wait(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// selectHistoryMetrics validates the requested names, nothing selects the defaults
+func (c *KubernetesClient) selectHistoryMetrics(group string, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return metrics.DefaultHistoryMetrics, nil
+	}
+
+	selected := make([]string, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if name == metrics.MetricsAll {
+			return c.queries.names(group), nil
+		}
+		if !c.queries.has(group, name) {
+			return nil, fmt.Errorf("%w %q, available: %s", metrics.ErrUnknownMetric, name, strings.Join(c.queries.names(group), ", "))
+		}
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			selected = append(selected, name)
+		}
+	}
+	return selected, nil
+}
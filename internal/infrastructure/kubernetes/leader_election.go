@@ -0,0 +1,206 @@
+package kubernetes
+
+import (
+	"context"
+	"main/internal/config"
+	"main/internal/domain/leadership"
+	"main/pkg"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// LeaderElector holds a coordination.k8s.io Lease so only one replica runs the watchers.
+// With leader election disabled the replica considers itself the leader for its whole lifetime.
+type LeaderElector struct {
+	logger    pkg.Logger
+	client    kubernetes.Interface
+	enabled   bool
+	identity  string
+	namespace string
+	leaseName string
+
+	mu        sync.RWMutex
+	leader    string
+	isLeader  bool
+	onStarted []func(ctx context.Context)
+	onStopped []func()
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewLeaderElector(lc fx.Lifecycle, logger pkg.Logger, env config.Env, kubernetesClient *KubernetesClient) *LeaderElector {
+	elector := newLeaderElector(logger, kubernetesClient.clientset, env)
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			elector.Start()
+			return nil
+		},
+		OnStop: elector.Stop,
+	})
+
+	return elector
+}
+
+func newLeaderElector(logger pkg.Logger, client kubernetes.Interface, env config.Env) *LeaderElector {
+	identity := env.PodName
+	if identity == "" {
+		identity, _ = os.Hostname()
+	}
+
+	return &LeaderElector{
+		logger:    logger,
+		client:    client,
+		enabled:   env.LeaderElection != "false",
+		identity:  identity,
+		namespace: env.LeaderElectionNamespace,
+		leaseName: env.LeaderElectionLease,
+		done:      make(chan struct{}),
+	}
+}
+
+func (e *LeaderElector) OnStartedLeading(fn func(ctx context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onStarted = append(e.onStarted, fn)
+}
+
+func (e *LeaderElector) OnStoppedLeading(fn func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onStopped = append(e.onStopped, fn)
+}
+
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isLeader
+}
+
+func (e *LeaderElector) Status() leadership.Status {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return leadership.Status{
+		Enabled:  e.enabled,
+		Identity: e.identity,
+		Leader:   e.leader,
+		IsLeader: e.isLeader,
+	}
+}
+
+func (e *LeaderElector) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+
+	if !e.enabled {
+		e.logger.Info("Leader election is disabled, running as the leader")
+		go func() {
+			defer close(e.done)
+			e.startedLeading(ctx)
+			<-ctx.Done()
+			e.stoppedLeading()
+		}()
+		return
+	}
+
+	go func() {
+		defer close(e.done)
+		// Run returns as soon as the leadership is lost, campaign again until stopped
+		for ctx.Err() == nil {
+			elector, err := leaderelection.NewLeaderElector(e.config())
+			if err != nil {
+				e.logger.Errorf("Failed to create leader elector: %v", err)
+				return
+			}
+			elector.Run(ctx)
+		}
+	}()
+}
+
+func (e *LeaderElector) Stop(ctx context.Context) error {
+	if e.cancel == nil {
+		return nil
+	}
+	e.cancel()
+
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *LeaderElector) config() leaderelection.LeaderElectionConfig {
+	return leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      e.leaseName,
+				Namespace: e.namespace,
+			},
+			Client: e.client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: e.identity,
+			},
+		},
+		ReleaseOnCancel: true,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: e.startedLeading,
+			OnStoppedLeading: e.stoppedLeading,
+			OnNewLeader: func(identity string) {
+				e.mu.Lock()
+				e.leader = identity
+				e.mu.Unlock()
+				e.logger.Infof("Current leader is %s", identity)
+			},
+		},
+	}
+}
+
+func (e *LeaderElector) startedLeading(ctx context.Context) {
+	e.mu.Lock()
+	e.isLeader = true
+	e.leader = e.identity
+	callbacks := append([]func(ctx context.Context){}, e.onStarted...)
+	e.mu.Unlock()
+
+	e.logger.Infof("Started leading as %s", e.identity)
+	for _, fn := range callbacks {
+		fn(ctx)
+	}
+}
+
+func (e *LeaderElector) stoppedLeading() {
+	e.mu.Lock()
+	wasLeader := e.isLeader
+	e.isLeader = false
+	callbacks := append([]func(){}, e.onStopped...)
+	e.mu.Unlock()
+
+	// client-go calls OnStoppedLeading even if the leadership was never acquired
+	if !wasLeader {
+		return
+	}
+
+	e.logger.Infof("Stopped leading as %s", e.identity)
+	for _, fn := range callbacks {
+		fn()
+	}
+}
@@ -0,0 +1,147 @@
+package kubernetes
+
+import (
+	"context"
+	"main/internal/config"
+	"main/pkg"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testLeaseNamespace = "default"
+	testLeaseName      = "test-leader"
+)
+
+func newTestElector(t *testing.T, client kubernetes.Interface, identity string, enabled bool) *LeaderElector {
+	t.Helper()
+	env := config.Env{
+		LeaderElection:          "true",
+		LeaderElectionNamespace: testLeaseNamespace,
+		LeaderElectionLease:     testLeaseName,
+		PodName:                 identity,
+	}
+	if !enabled {
+		env.LeaderElection = "false"
+	}
+	return newLeaderElector(pkg.GetLogger(env), client, env)
+}
+
+func stopElector(t *testing.T, elector *LeaderElector) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := elector.Stop(ctx); err != nil {
+		t.Fatalf("failed to stop elector: %v", err)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool, message string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal(message)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLeaderElectorAcquiresLease(t *testing.T) {
+	client := fake.NewClientset()
+	elector := newTestElector(t, client, "replica-a", true)
+
+	var started atomic.Int32
+	elector.OnStartedLeading(func(context.Context) { started.Add(1) })
+	elector.Start()
+	defer stopElector(t, elector)
+
+	waitFor(t, elector.IsLeader, "replica-a did not become the leader")
+	if started.Load() != 1 {
+		t.Fatalf("OnStartedLeading called %d times, want 1", started.Load())
+	}
+
+	status := elector.Status()
+	if !status.Enabled || !status.IsLeader || status.Identity != "replica-a" || status.Leader != "replica-a" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	lease, err := client.CoordinationV1().Leases(testLeaseNamespace).Get(context.Background(), testLeaseName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("lease was not created: %v", err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != "replica-a" {
+		t.Fatalf("lease holder is %v, want replica-a", lease.Spec.HolderIdentity)
+	}
+}
+
+func TestLeaderElectorStopReleasesLease(t *testing.T) {
+	client := fake.NewClientset()
+	elector := newTestElector(t, client, "replica-a", true)
+
+	var stopped atomic.Int32
+	elector.OnStoppedLeading(func() { stopped.Add(1) })
+	elector.Start()
+	waitFor(t, elector.IsLeader, "replica-a did not become the leader")
+
+	stopElector(t, elector)
+
+	if elector.IsLeader() {
+		t.Fatal("elector still reports leadership after Stop")
+	}
+	if stopped.Load() != 1 {
+		t.Fatalf("OnStoppedLeading called %d times, want 1", stopped.Load())
+	}
+	lease, err := client.CoordinationV1().Leases(testLeaseNamespace).Get(context.Background(), testLeaseName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get lease: %v", err)
+	}
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != "" {
+		t.Fatalf("lease is still held by %s", *lease.Spec.HolderIdentity)
+	}
+}
+
+func TestLeaderElectorSecondReplicaFollows(t *testing.T) {
+	client := fake.NewClientset()
+	leader := newTestElector(t, client, "replica-a", true)
+	leader.Start()
+	defer stopElector(t, leader)
+	waitFor(t, leader.IsLeader, "replica-a did not become the leader")
+
+	follower := newTestElector(t, client, "replica-b", true)
+	var started atomic.Int32
+	follower.OnStartedLeading(func(context.Context) { started.Add(1) })
+	follower.Start()
+	defer stopElector(t, follower)
+
+	waitFor(t, func() bool { return follower.Status().Leader == "replica-a" }, "replica-b did not observe the leader")
+	if follower.IsLeader() || started.Load() != 0 {
+		t.Fatal("replica-b became the leader while the lease is held")
+	}
+	if !leader.IsLeader() {
+		t.Fatal("replica-a lost the leadership")
+	}
+}
+
+func TestLeaderElectorDisabled(t *testing.T) {
+	elector := newTestElector(t, fake.NewClientset(), "replica-a", false)
+
+	var started atomic.Int32
+	elector.OnStartedLeading(func(context.Context) { started.Add(1) })
+	elector.Start()
+	defer stopElector(t, elector)
+
+	waitFor(t, elector.IsLeader, "replica-a is not the leader with leader election disabled")
+	status := elector.Status()
+	if status.Enabled || status.Leader != "replica-a" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if started.Load() != 1 {
+		t.Fatalf("OnStartedLeading called %d times, want 1", started.Load())
+	}
+}
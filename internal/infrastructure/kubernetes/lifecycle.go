@@ -0,0 +1,354 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"main/internal/domain/events"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// lifecycleResyncPeriod also defines how often pending pods are checked against the threshold
+const lifecycleResyncPeriod = 30 * time.Second
+
+// Node events are recorded by the kubelet in the default namespace, synthetic ones follow it
+const nodeEventsNamespace = "default"
+
+const (
+	reasonCrashLoopBackOff  = "CrashLoopBackOff"
+	reasonOOMKilled         = "OOMKilled"
+	reasonImagePullBackOff  = "ImagePullBackOff"
+	reasonErrImagePull      = "ErrImagePull"
+	reasonPodPendingTooLong = "PodPendingTooLong"
+	reasonNodeNotReady      = "NodeNotReady"
+)
+
+// WatchPodLifecycle generates synthetic events from pod state transitions in the namespace,
+// such as containers entering CrashLoopBackOff or pods staying Pending longer than pendingThreshold.
+func (c KubernetesClient) WatchPodLifecycle(ctx context.Context, namespace string, pendingThreshold time.Duration) (chan events.Event, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(c.clientset, lifecycleResyncPeriod, informers.WithNamespace(namespace))
+	informer := factory.Core().V1().Pods().Informer()
+
+	eventChan := make(chan events.Event, 100)
+	detector := newPodLifecycleDetector(pendingThreshold)
+	emit := emitter(ctx, eventChan)
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if pod, ok := obj.(*corev1.Pod); ok && !isInInitialList {
+				emit(withPodOwner(pod, detector.transitions(nil, pod)))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod, oldOk := oldObj.(*corev1.Pod)
+			newPod, newOk := newObj.(*corev1.Pod)
+			if oldOk && newOk {
+				emit(withPodOwner(newPod, detector.transitions(oldPod, newPod)))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				detector.forget(pod)
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return runInformer(ctx, factory, informer, eventChan)
+}
+
+// WatchNodeLifecycle generates synthetic events when a node goes from Ready to NotReady
+func (c KubernetesClient) WatchNodeLifecycle(ctx context.Context) (chan events.Event, error) {
+	factory := informers.NewSharedInformerFactory(c.clientset, lifecycleResyncPeriod)
+	informer := factory.Core().V1().Nodes().Informer()
+
+	eventChan := make(chan events.Event, 100)
+	emit := emitter(ctx, eventChan)
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNode, oldOk := oldObj.(*corev1.Node)
+			newNode, newOk := newObj.(*corev1.Node)
+			if oldOk && newOk {
+				emit(nodeTransitions(oldNode, newNode))
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return runInformer(ctx, factory, informer, eventChan)
+}
+
+// withPodOwner sets the direct controller of the pod as owner of its generated events without
+// calling the API server, the ingester resolves the controllers above it
+func withPodOwner(pod *corev1.Pod, generated []events.Event) []events.Event {
+	if len(generated) == 0 {
+		return generated
+	}
+	owner := podInvolvedObject(pod, "")
+	if controller := metav1.GetControllerOfNoCopy(pod); controller != nil {
+		owner = events.InvolvedObject{
+			Kind:       controller.Kind,
+			Name:       controller.Name,
+			Namespace:  pod.Namespace,
+			UID:        string(controller.UID),
+			APIVersion: controller.APIVersion,
+		}
+	}
+	for i := range generated {
+		generated[i].SetOwner(owner)
+	}
+	return generated
+}
+
+func runInformer(ctx context.Context, factory informers.SharedInformerFactory, informer cache.SharedIndexInformer, eventChan chan events.Event) (chan events.Event, error) {
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		factory.Shutdown()
+		return nil, fmt.Errorf("failed to sync informer cache")
+	}
+
+	go func() {
+		<-ctx.Done()
+		factory.Shutdown()
+		close(eventChan)
+	}()
+
+	return eventChan, nil
+}
+
+func emitter(ctx context.Context, eventChan chan events.Event) func([]events.Event) {
+	return func(generated []events.Event) {
+		for _, event := range generated {
+			select {
+			case eventChan <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+type podLifecycleDetector struct {
+	pendingThreshold time.Duration
+	mu               sync.Mutex
+	reportedPending  map[string]struct{}
+}
+
+func newPodLifecycleDetector(pendingThreshold time.Duration) *podLifecycleDetector {
+	return &podLifecycleDetector{
+		pendingThreshold: pendingThreshold,
+		reportedPending:  make(map[string]struct{}),
+	}
+}
+
+func (d *podLifecycleDetector) forget(pod *corev1.Pod) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.reportedPending, string(pod.UID))
+}
+
+// transitions compares two versions of a pod, oldPod is nil for a newly created pod
+func (d *podLifecycleDetector) transitions(oldPod, newPod *corev1.Pod) []events.Event {
+	result := make([]events.Event, 0)
+
+	statuses := append(append([]corev1.ContainerStatus{}, newPod.Status.InitContainerStatuses...), newPod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		var oldStatus *corev1.ContainerStatus
+		if oldPod != nil {
+			oldStatus = findContainerStatus(oldPod, status.Name)
+		}
+
+		waitingReason := containerWaitingReason(&status)
+		oldWaitingReason := containerWaitingReason(oldStatus)
+
+		switch {
+		case waitingReason == reasonCrashLoopBackOff && oldWaitingReason != reasonCrashLoopBackOff:
+			message := fmt.Sprintf("Container %s is in CrashLoopBackOff after %d restarts", status.Name, status.RestartCount)
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				message += fmt.Sprintf(", last exit code %d (%s)", terminated.ExitCode, terminated.Reason)
+			}
+			result = append(result, podEvent(newPod, status.Name, reasonCrashLoopBackOff, message, status.RestartCount))
+		case isImagePullReason(waitingReason) && !isImagePullReason(oldWaitingReason):
+			message := fmt.Sprintf("Container %s can't pull image %s: %s", status.Name, status.Image, status.State.Waiting.Message)
+			result = append(result, podEvent(newPod, status.Name, reasonImagePullBackOff, message, 1))
+		}
+
+		if terminated := oomTermination(&status); terminated != nil {
+			if oldTerminated := oomTermination(oldStatus); oldTerminated == nil || !oldTerminated.FinishedAt.Equal(&terminated.FinishedAt) {
+				message := fmt.Sprintf("Container %s was OOMKilled", status.Name)
+				result = append(result, podEvent(newPod, status.Name, reasonOOMKilled, message, max(status.RestartCount, 1)))
+			}
+		}
+	}
+
+	if event, ok := d.pendingTooLong(newPod); ok {
+		result = append(result, event)
+	}
+
+	return result
+}
+
+func (d *podLifecycleDetector) pendingTooLong(pod *corev1.Pod) (events.Event, bool) {
+	if d.pendingThreshold <= 0 || pod.Status.Phase != corev1.PodPending {
+		return events.Event{}, false
+	}
+	pendingFor := time.Since(pod.CreationTimestamp.Time)
+	if pendingFor < d.pendingThreshold {
+		return events.Event{}, false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, reported := d.reportedPending[string(pod.UID)]; reported {
+		return events.Event{}, false
+	}
+	d.reportedPending[string(pod.UID)] = struct{}{}
+
+	message := fmt.Sprintf("Pod has been Pending for %s", pendingFor.Truncate(time.Second))
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status != corev1.ConditionTrue && condition.Message != "" {
+			message += ": " + condition.Message
+		}
+	}
+	return podEvent(pod, "", reasonPodPendingTooLong, message, 1), true
+}
+
+func nodeTransitions(oldNode, newNode *corev1.Node) []events.Event {
+	oldReady := findNodeCondition(oldNode, corev1.NodeReady)
+	newReady := findNodeCondition(newNode, corev1.NodeReady)
+	if oldReady == nil || newReady == nil {
+		return nil
+	}
+	if oldReady.Status != corev1.ConditionTrue || newReady.Status == corev1.ConditionTrue {
+		return nil
+	}
+
+	message := fmt.Sprintf("Node %s changed from Ready to NotReady", newNode.Name)
+	if newReady.Message != "" {
+		message += ": " + newReady.Message
+	}
+
+	now := time.Now()
+	event := events.Event{
+		ID:             syntheticEventID(string(newNode.UID), reasonNodeNotReady, newReady.LastTransitionTime.UTC().Format(time.RFC3339)),
+		Namespace:      nodeEventsNamespace,
+		Name:           newNode.Name + "." + strings.ToLower(reasonNodeNotReady),
+		Reason:         reasonNodeNotReady,
+		Message:        message,
+		Type:           corev1.EventTypeWarning,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Source:         events.EventSourceSynthetic,
+	}
+	node := events.InvolvedObject{
+		Kind:       "Node",
+		Name:       newNode.Name,
+		UID:        string(newNode.UID),
+		APIVersion: "v1",
+	}
+	event.SetInvolvedObject(node)
+	event.SetOwner(node)
+	return []events.Event{event}
+}
+
+// podEvent builds a synthetic event, the ID is stable per pod, container and reason
+// so repeated transitions update the same row like the API server aggregates events
+func podEvent(pod *corev1.Pod, container string, reason string, message string, count int32) events.Event {
+	now := time.Now()
+	name := pod.Name + "." + strings.ToLower(reason)
+	if container != "" {
+		name = pod.Name + "." + container + "." + strings.ToLower(reason)
+	}
+	event := events.Event{
+		ID:             syntheticEventID(string(pod.UID), container, reason),
+		Namespace:      pod.Namespace,
+		Name:           name,
+		Reason:         reason,
+		Message:        message,
+		Type:           corev1.EventTypeWarning,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          count,
+		Source:         events.EventSourceSynthetic,
+	}
+	event.SetInvolvedObject(podInvolvedObject(pod, container))
+	return event
+}
+
+// podInvolvedObject references the pod, or one of its containers the way the kubelet does
+func podInvolvedObject(pod *corev1.Pod, container string) events.InvolvedObject {
+	object := events.InvolvedObject{
+		Kind:       "Pod",
+		Name:       pod.Name,
+		Namespace:  pod.Namespace,
+		UID:        string(pod.UID),
+		APIVersion: "v1",
+	}
+	if container != "" {
+		object.FieldPath = fmt.Sprintf("spec.containers{%s}", container)
+	}
+	return object
+}
+
+func syntheticEventID(parts ...string) string {
+	hash := sha1.Sum([]byte(strings.Join(parts, "/")))
+	return "synthetic-" + hex.EncodeToString(hash[:])
+}
+
+func findContainerStatus(pod *corev1.Pod, name string) *corev1.ContainerStatus {
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for i := range statuses {
+			if statuses[i].Name == name {
+				return &statuses[i]
+			}
+		}
+	}
+	return nil
+}
+
+func findNodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == conditionType {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func containerWaitingReason(status *corev1.ContainerStatus) string {
+	if status == nil || status.State.Waiting == nil {
+		return ""
+	}
+	return status.State.Waiting.Reason
+}
+
+func isImagePullReason(reason string) bool {
+	return reason == reasonImagePullBackOff || reason == reasonErrImagePull
+}
+
+func oomTermination(status *corev1.ContainerStatus) *corev1.ContainerStateTerminated {
+	if status == nil {
+		return nil
+	}
+	if terminated := status.State.Terminated; terminated != nil && terminated.Reason == reasonOOMKilled {
+		return terminated
+	}
+	if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason == reasonOOMKilled {
+		return terminated
+	}
+	return nil
+}
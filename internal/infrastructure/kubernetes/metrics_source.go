@@ -0,0 +1,266 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/internal/infrastructure/prometheus"
+	"main/pkg"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"golang.org/x/sync/errgroup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+const (
+	MetricsSourcePrometheus    = "prometheus"
+	MetricsSourceMetricsServer = "metrics-server"
+	// MetricsSourceAuto uses Prometheus and falls back to metrics-server when it fails
+	MetricsSourceAuto = "auto"
+)
+
+const (
+	// fallbackRetryAfter is how long the primary source is skipped after it failed
+	fallbackRetryAfter = time.Minute
+	// fallbackPrimaryTimeout leaves the rest of the request time to the secondary source
+	fallbackPrimaryTimeout = 5 * time.Second
+)
+
+// resourceUsage holds CPU in millicores and memory in bytes
+type resourceUsage struct {
+	cpu    float64
+	memory float64
+}
+
+// metricsSource provides the current usage of pods and nodes
+type metricsSource interface {
+	name() string
+	podUsage(ctx context.Context, namespace string) (map[containerKey]resourceUsage, error)
+	nodeUsage(ctx context.Context) (map[string]resourceUsage, error)
+}
+
+func newMetricsSource(source string, logger pkg.Logger, prometheusClient prometheus.PrometheusClient, queries *querySet, nodeInstances *nodeInstanceResolver, metricsClient versioned.Interface) (metricsSource, error) {
+	prometheusSource := &prometheusMetricsSource{client: prometheusClient, queries: queries, nodeInstances: nodeInstances}
+	metricsServerSource := &metricsServerSource{client: metricsClient}
+
+	switch source {
+	case MetricsSourcePrometheus:
+		return prometheusSource, nil
+	case MetricsSourceMetricsServer:
+		return metricsServerSource, nil
+	case MetricsSourceAuto, "":
+		return &fallbackMetricsSource{logger: logger, primary: prometheusSource, secondary: metricsServerSource}, nil
+	}
+	return nil, fmt.Errorf("unknown metrics source %q", source)
+}
+
+type prometheusMetricsSource struct {
+	client        prometheus.PrometheusClient
+	queries       *querySet
+	nodeInstances *nodeInstanceResolver
+}
+
+func (s *prometheusMetricsSource) name() string {
+	return MetricsSourcePrometheus
+}
+
+func (s *prometheusMetricsSource) podUsage(ctx context.Context, namespace string) (map[containerKey]resourceUsage, error) {
+	vectors, err := s.queryVectors(ctx, []string{queryPodCPU, queryPodMemory}, queryData{Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+
+	podLabel := model.LabelName(s.queries.label("pod"))
+	containerLabel := model.LabelName(s.queries.label("container"))
+	usage := make(map[containerKey]resourceUsage)
+	for metric, vector := range vectors {
+		for _, sample := range vector {
+			key := containerKey{pod: string(sample.Metric[podLabel]), container: string(sample.Metric[containerLabel])}
+			containerUsage := usage[key]
+			if metric == queryPodCPU {
+				containerUsage.cpu = float64(sample.Value)
+			} else {
+				containerUsage.memory = float64(sample.Value)
+			}
+			usage[key] = containerUsage
+		}
+	}
+	return usage, nil
+}
+
+func (s *prometheusMetricsSource) nodeUsage(ctx context.Context) (map[string]resourceUsage, error) {
+	vectors, err := s.queryVectors(ctx, []string{queryNodeCPU, queryNodeMemory}, queryData{})
+	if err != nil {
+		return nil, err
+	}
+
+	nodeLabel := model.LabelName(s.queries.label("node"))
+	values := make([]string, 0)
+	for _, vector := range vectors {
+		for _, sample := range vector {
+			values = append(values, string(sample.Metric[nodeLabel]))
+		}
+	}
+	nodeNames, err := s.nodeInstances.nodeNames(ctx, values)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]resourceUsage)
+	for metric, vector := range vectors {
+		for _, sample := range vector {
+			node, ok := nodeNames[string(sample.Metric[nodeLabel])]
+			if !ok {
+				continue
+			}
+			nodeUsage := usage[node]
+			if metric == queryNodeCPU {
+				nodeUsage.cpu = float64(sample.Value)
+			} else {
+				nodeUsage.memory = float64(sample.Value)
+			}
+			usage[node] = nodeUsage
+		}
+	}
+	return usage, nil
+}
+
+// queryVectors runs the instant queries concurrently, any failed query fails the whole call
+// so a caller can fall back to another source
+func (s *prometheusMetricsSource) queryVectors(ctx context.Context, names []string, data queryData) (map[string]model.Vector, error) {
+	var mu sync.Mutex
+	result := make(map[string]model.Vector, len(names))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(maxConcurrentQueries)
+	for _, metric := range names {
+		group.Go(func() error {
+			query, err := s.queries.render(queryGroupUsage, metric, data)
+			if err != nil {
+				return err
+			}
+			value, err := s.client.GetMetricValueContext(groupCtx, query)
+			if err != nil {
+				return fmt.Errorf("failed to query %s usage: %w", metric, err)
+			}
+			vector, _ := value.(model.Vector)
+
+			mu.Lock()
+			result[metric] = vector
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+type metricsServerSource struct {
+	client versioned.Interface
+}
+
+func (s *metricsServerSource) name() string {
+	return MetricsSourceMetricsServer
+}
+
+func (s *metricsServerSource) podUsage(ctx context.Context, namespace string) (map[containerKey]resourceUsage, error) {
+	list, err := s.client.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod metrics: %w", err)
+	}
+
+	usage := make(map[containerKey]resourceUsage)
+	for _, pod := range list.Items {
+		for _, container := range pod.Containers {
+			usage[containerKey{pod: pod.Name, container: container.Name}] = resourceUsage{
+				cpu:    float64(container.Usage.Cpu().MilliValue()),
+				memory: float64(container.Usage.Memory().Value()),
+			}
+		}
+	}
+	return usage, nil
+}
+
+func (s *metricsServerSource) nodeUsage(ctx context.Context) (map[string]resourceUsage, error) {
+	list, err := s.client.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node metrics: %w", err)
+	}
+
+	usage := make(map[string]resourceUsage, len(list.Items))
+	for _, node := range list.Items {
+		usage[node.Name] = resourceUsage{
+			cpu:    float64(node.Usage.Cpu().MilliValue()),
+			memory: float64(node.Usage.Memory().Value()),
+		}
+	}
+	return usage, nil
+}
+
+// fallbackMetricsSource asks the secondary source when the primary one fails,
+// after a failure the primary is skipped for a while so requests don't wait on it
+type fallbackMetricsSource struct {
+	logger    pkg.Logger
+	primary   metricsSource
+	secondary metricsSource
+
+	mu       sync.Mutex
+	failedAt time.Time
+}
+
+func (s *fallbackMetricsSource) name() string {
+	return s.primary.name() + "," + s.secondary.name()
+}
+
+func (s *fallbackMetricsSource) podUsage(ctx context.Context, namespace string) (map[containerKey]resourceUsage, error) {
+	return withFallback(s, ctx, func(ctx context.Context, source metricsSource) (map[containerKey]resourceUsage, error) {
+		return source.podUsage(ctx, namespace)
+	})
+}
+
+func (s *fallbackMetricsSource) nodeUsage(ctx context.Context) (map[string]resourceUsage, error) {
+	return withFallback(s, ctx, func(ctx context.Context, source metricsSource) (map[string]resourceUsage, error) {
+		return source.nodeUsage(ctx)
+	})
+}
+
+func withFallback[T any](s *fallbackMetricsSource, ctx context.Context, fetch func(context.Context, metricsSource) (T, error)) (T, error) {
+	s.mu.Lock()
+	skipPrimary := !s.failedAt.IsZero() && time.Since(s.failedAt) < fallbackRetryAfter
+	s.mu.Unlock()
+
+	var primaryErr error
+	if !skipPrimary {
+		primaryCtx, cancel := context.WithTimeout(ctx, fallbackPrimaryTimeout)
+		result, err := fetch(primaryCtx, s.primary)
+		cancel()
+		if err == nil {
+			s.mu.Lock()
+			s.failedAt = time.Time{}
+			s.mu.Unlock()
+			return result, nil
+		}
+		// The caller gave up, that says nothing about the primary source
+		if ctx.Err() != nil {
+			return result, err
+		}
+
+		primaryErr = err
+		s.logger.Errorf("Metrics source %s failed, falling back to %s: %v", s.primary.name(), s.secondary.name(), err)
+		s.mu.Lock()
+		s.failedAt = time.Now()
+		s.mu.Unlock()
+	}
+
+	result, err := fetch(ctx, s.secondary)
+	if err != nil {
+		return result, errors.Join(primaryErr, err)
+	}
+	return result, nil
+}
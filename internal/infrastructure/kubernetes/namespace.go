@@ -2,8 +2,14 @@ package kubernetes
 
 import (
 	"context"
+	"fmt"
+	"main/internal/domain/events"
 
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
 )
 
 func (c KubernetesClient) GetNamespaces() ([]string, error) {
@@ -17,3 +23,71 @@ func (c KubernetesClient) GetNamespaces() ([]string, error) {
 	}
 	return res, nil
 }
+
+// WatchNamespaces reports namespace creations and deletions. Selected is set for namespaces
+// matching the label selector, an empty selector selects nothing.
+func (c KubernetesClient) WatchNamespaces(ctx context.Context, selector string) (chan events.NamespaceChange, error) {
+	matcher := labels.Nothing()
+	if selector != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace selector %q: %w", selector, err)
+		}
+		matcher = parsed
+	}
+
+	factory := informers.NewSharedInformerFactory(c.clientset, 0)
+	informer := factory.Core().V1().Namespaces().Informer()
+
+	changeChan := make(chan events.NamespaceChange, 100)
+	send := func(change events.NamespaceChange) {
+		select {
+		case changeChan <- change:
+		case <-ctx.Done():
+		}
+	}
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if namespace, ok := obj.(*corev1.Namespace); ok {
+				send(events.NamespaceChange{
+					Namespace: namespace.Name,
+					Selected:  matcher.Matches(labels.Set(namespace.Labels)),
+				})
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNamespace, oldOk := oldObj.(*corev1.Namespace)
+			newNamespace, newOk := newObj.(*corev1.Namespace)
+			// Only a label change can make a namespace selected
+			if oldOk && newOk && !matcher.Matches(labels.Set(oldNamespace.Labels)) && matcher.Matches(labels.Set(newNamespace.Labels)) {
+				send(events.NamespaceChange{Namespace: newNamespace.Name, Selected: true})
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if namespace, ok := obj.(*corev1.Namespace); ok {
+				send(events.NamespaceChange{Namespace: namespace.Name, Deleted: true})
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		factory.Shutdown()
+		return nil, fmt.Errorf("failed to sync namespace informer cache")
+	}
+
+	go func() {
+		<-ctx.Done()
+		factory.Shutdown()
+		close(changeChan)
+	}()
+
+	return changeChan, nil
+}
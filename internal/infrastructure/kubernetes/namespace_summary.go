@@ -0,0 +1,149 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/metrics"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *KubernetesClient) GetNamespaceSummary(ctx context.Context, namespace string) (*metrics.NamespaceSummary, error) {
+	if _, err := c.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: namespace %s", metrics.ErrNotFound, namespace)
+		}
+		return nil, err
+	}
+
+	var pods *corev1.PodList
+	var quotas *corev1.ResourceQuotaList
+	var limitRanges *corev1.LimitRangeList
+	var usage map[containerKey]resourceUsage
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		pods, err = c.clientset.CoreV1().Pods(namespace).List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		quotas, err = c.clientset.CoreV1().ResourceQuotas(namespace).List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		limitRanges, err = c.clientset.CoreV1().LimitRanges(namespace).List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		usage = c.namespaceUsage(groupCtx, namespace)
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	summary := &metrics.NamespaceSummary{
+		Namespace:   namespace,
+		Pods:        len(pods.Items),
+		PodsByPhase: make(map[string]int),
+		Quotas:      make([]metrics.ResourceQuotaSummary, 0, len(quotas.Items)),
+		LimitRanges: make([]metrics.LimitRangeSummary, 0, len(limitRanges.Items)),
+	}
+
+	if usage != nil {
+		cpuUsage, memoryUsage := 0.0, 0.0
+		for _, containerUsage := range usage {
+			cpuUsage += containerUsage.cpu
+			memoryUsage += containerUsage.memory
+		}
+		cpu := int64(cpuUsage)
+		memory := int64(memoryUsage) / 1024 / 1024 // MiB
+		summary.CPUUsage = &cpu
+		summary.MemoryUsage = &memory
+	}
+
+	memoryRequest, memoryLimit := int64(0), int64(0)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		summary.PodsByPhase[string(pod.Status.Phase)]++
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		resources := effectivePodResources(pod)
+		summary.CPURequest += resources.cpuRequest
+		summary.CPULimit += resources.cpuLimit
+		memoryRequest += resources.memoryRequest
+		memoryLimit += resources.memoryLimit
+		if resources.cpuLimit == 0 || resources.memoryLimit == 0 {
+			summary.PodsWithoutLimits++
+		}
+	}
+	summary.MemoryRequest = memoryRequest / 1024 / 1024 // MiB
+	summary.MemoryLimit = memoryLimit / 1024 / 1024     // MiB
+
+	for _, quota := range quotas.Items {
+		quotaSummary := metrics.ResourceQuotaSummary{
+			Name:      quota.Name,
+			Resources: make([]metrics.QuotaResource, 0, len(quota.Status.Hard)),
+		}
+		for name, hard := range quota.Status.Hard {
+			used := quota.Status.Used[name]
+			resource := metrics.QuotaResource{
+				Resource: string(name),
+				Hard:     hard.String(),
+				Used:     used.String(),
+			}
+			if hardValue := hard.AsApproximateFloat64(); hardValue > 0 {
+				utilization := used.AsApproximateFloat64() / hardValue * 100
+				resource.UtilizationPercent = &utilization
+				if summary.MaxQuotaUtilization == nil || utilization > *summary.MaxQuotaUtilization {
+					summary.MaxQuotaUtilization = &utilization
+				}
+			}
+			quotaSummary.Resources = append(quotaSummary.Resources, resource)
+		}
+		sort.Slice(quotaSummary.Resources, func(i, j int) bool {
+			return quotaSummary.Resources[i].Resource < quotaSummary.Resources[j].Resource
+		})
+		summary.Quotas = append(summary.Quotas, quotaSummary)
+	}
+
+	for _, limitRange := range limitRanges.Items {
+		limitRangeSummary := metrics.LimitRangeSummary{
+			Name:   limitRange.Name,
+			Limits: make([]metrics.LimitRangeItem, 0, len(limitRange.Spec.Limits)),
+		}
+		for _, item := range limitRange.Spec.Limits {
+			limitRangeSummary.Limits = append(limitRangeSummary.Limits, metrics.LimitRangeItem{
+				Type:                 string(item.Type),
+				Default:              resourceListStrings(item.Default),
+				DefaultRequest:       resourceListStrings(item.DefaultRequest),
+				Min:                  resourceListStrings(item.Min),
+				Max:                  resourceListStrings(item.Max),
+				MaxLimitRequestRatio: resourceListStrings(item.MaxLimitRequestRatio),
+			})
+		}
+		summary.LimitRanges = append(summary.LimitRanges, limitRangeSummary)
+	}
+
+	return summary, nil
+}
+
+func resourceListStrings(list corev1.ResourceList) map[string]string {
+	if len(list) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(list))
+	for name, quantity := range list {
+		result[string(name)] = quantity.String()
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package kubernetes
+
+import (
+	"context"
+	"main/internal/domain/metrics"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// resourceTotals accumulates one resource in its base unit, millicores or bytes
+type resourceTotals struct {
+	allocatable int64
+	requests    int64
+	limits      int64
+	free        int64
+	usage       float64
+	// usageAllocatable is the allocatable of the nodes the usage is known for
+	usageAllocatable int64
+	hasUsage         bool
+}
+
+func (t *resourceTotals) add(other resourceTotals, schedulable bool) {
+	t.allocatable += other.allocatable
+	t.requests += other.requests
+	t.limits += other.limits
+	if schedulable {
+		t.free += other.free
+	}
+	if other.hasUsage {
+		t.usage += other.usage
+		t.usageAllocatable += other.usageAllocatable
+		t.hasUsage = true
+	}
+}
+
+// allocation converts the totals, unit is the divisor to the unit of the response
+func (t resourceTotals) allocation(unit int64) metrics.ResourceAllocation {
+	allocation := metrics.ResourceAllocation{
+		Allocatable: t.allocatable / unit,
+		Requests:    t.requests / unit,
+		Limits:      t.limits / unit,
+		Free:        t.free / unit,
+	}
+	if t.allocatable > 0 {
+		allocation.RequestsPercent = float64(t.requests) / float64(t.allocatable) * 100
+		allocation.OvercommitRatio = float64(t.limits) / float64(t.allocatable)
+	}
+	if t.hasUsage {
+		usage := int64(t.usage) / unit
+		allocation.Usage = &usage
+		if t.usageAllocatable > 0 {
+			percent := t.usage / float64(t.usageAllocatable) * 100
+			allocation.UsagePercent = &percent
+		}
+	}
+	return allocation
+}
+
+// GetNodeAllocation sums the requests and limits of the pods on each node against its
+// allocatable, like "kubectl describe node" does, together with the real usage
+func (c *KubernetesClient) GetNodeAllocation(ctx context.Context) (*metrics.ClusterAllocation, error) {
+	var nodes *corev1.NodeList
+	var pods *corev1.PodList
+	var usage map[string]resourceUsage
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		nodes, err = c.clientset.CoreV1().Nodes().List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		// Finished pods don't hold their resources anymore
+		selector := fields.AndSelectors(
+			fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+			fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+		)
+		var err error
+		pods, err = c.clientset.CoreV1().Pods(metav1.NamespaceAll).List(groupCtx, metav1.ListOptions{FieldSelector: selector.String()})
+		return err
+	})
+	group.Go(func() error {
+		metricsCtx, cancel := context.WithTimeout(groupCtx, podMetricsTimeout)
+		defer cancel()
+		var err error
+		usage, err = c.metrics.nodeUsage(metricsCtx)
+		if err != nil {
+			c.logger.Errorf("Failed to get node metrics: %v", err)
+		}
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	podsByNode := make(map[string][]*corev1.Pod)
+	for i := range pods.Items {
+		if nodeName := pods.Items[i].Spec.NodeName; nodeName != "" {
+			podsByNode[nodeName] = append(podsByNode[nodeName], &pods.Items[i])
+		}
+	}
+
+	result := &metrics.ClusterAllocation{
+		Nodes: make([]metrics.NodeAllocation, 0, len(nodes.Items)),
+		Total: metrics.NodeAllocation{Name: "total"},
+	}
+	var totalCPU, totalMemory resourceTotals
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		cpu := resourceTotals{allocatable: node.Status.Allocatable.Cpu().MilliValue()}
+		memory := resourceTotals{allocatable: node.Status.Allocatable.Memory().Value()}
+		allocation := metrics.NodeAllocation{
+			Name:          node.Name,
+			Status:        getNodeStatus(node),
+			Unschedulable: node.Spec.Unschedulable,
+			Pods:          len(podsByNode[node.Name]),
+			PodCapacity:   node.Status.Allocatable.Pods().Value(),
+		}
+
+		for _, pod := range podsByNode[node.Name] {
+			resources := effectivePodResources(pod)
+			cpu.requests += resources.cpuRequest
+			cpu.limits += resources.cpuLimit
+			memory.requests += resources.memoryRequest
+			memory.limits += resources.memoryLimit
+			if resources.cpuLimit == 0 || resources.memoryLimit == 0 {
+				allocation.PodsWithoutLimits++
+			}
+		}
+		// Static pods can request more than the node has, there's no negative headroom
+		cpu.free = max(cpu.allocatable-cpu.requests, 0)
+		memory.free = max(memory.allocatable-memory.requests, 0)
+
+		if nodeUsage, ok := usage[node.Name]; ok {
+			cpu.usage, cpu.usageAllocatable, cpu.hasUsage = nodeUsage.cpu, cpu.allocatable, true
+			memory.usage, memory.usageAllocatable, memory.hasUsage = nodeUsage.memory, memory.allocatable, true
+		}
+
+		allocation.CPU = cpu.allocation(1)
+		allocation.Memory = memory.allocation(1024 * 1024) // MiB
+		result.Nodes = append(result.Nodes, allocation)
+
+		schedulable := !node.Spec.Unschedulable && allocation.Status == "Ready"
+		totalCPU.add(cpu, schedulable)
+		totalMemory.add(memory, schedulable)
+		result.Total.Pods += allocation.Pods
+		result.Total.PodCapacity += allocation.PodCapacity
+		result.Total.PodsWithoutLimits += allocation.PodsWithoutLimits
+	}
+	result.Total.CPU = totalCPU.allocation(1)
+	result.Total.Memory = totalMemory.allocation(1024 * 1024) // MiB
+
+	sort.Slice(result.Nodes, func(i, j int) bool {
+		return result.Nodes[i].Name < result.Nodes[j].Name
+	})
+	return result, nil
+}
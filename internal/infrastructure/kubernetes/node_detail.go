@@ -0,0 +1,136 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/metrics"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// GetNode returns the node with its conditions, taints, resources and the pods scheduled on it
+func (c *KubernetesClient) GetNode(ctx context.Context, name string) (*metrics.NodeDetail, error) {
+	node, err := c.clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: node %s", metrics.ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pods *corev1.PodList
+	var nodeUsage *resourceUsage
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		pods, err = c.clientset.CoreV1().Pods(metav1.NamespaceAll).List(groupCtx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", name).String(),
+		})
+		return err
+	})
+	group.Go(func() error {
+		metricsCtx, cancel := context.WithTimeout(groupCtx, podMetricsTimeout)
+		defer cancel()
+		usage, err := c.metrics.nodeUsage(metricsCtx)
+		if err != nil {
+			c.logger.Errorf("Failed to get node metrics: %v", err)
+			return nil
+		}
+		if known, ok := usage[name]; ok {
+			nodeUsage = &known
+		}
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	usage := c.podsUsage(ctx, pods.Items)
+
+	detail := &metrics.NodeDetail{
+		Name:                    node.Name,
+		Roles:                   getNodeRoles(node),
+		Status:                  getNodeStatus(node),
+		Unschedulable:           node.Spec.Unschedulable,
+		Conditions:              make([]metrics.Condition, 0, len(node.Status.Conditions)),
+		Taints:                  make([]metrics.Taint, 0, len(node.Spec.Taints)),
+		Labels:                  node.Labels,
+		Addresses:               make([]metrics.NodeAddress, 0, len(node.Status.Addresses)),
+		Capacity:                resourceListStrings(node.Status.Capacity),
+		Allocatable:             resourceListStrings(node.Status.Allocatable),
+		KubeletVersion:          node.Status.NodeInfo.KubeletVersion,
+		ContainerRuntimeVersion: node.Status.NodeInfo.ContainerRuntimeVersion,
+		OSImage:                 node.Status.NodeInfo.OSImage,
+		OperatingSystem:         node.Status.NodeInfo.OperatingSystem,
+		Architecture:            node.Status.NodeInfo.Architecture,
+		KernelVersion:           node.Status.NodeInfo.KernelVersion,
+		CreatedAt:               node.CreationTimestamp.Format(time.RFC3339),
+		Pods:                    make([]metrics.PodMetrics, 0, len(pods.Items)),
+	}
+	if nodeUsage != nil {
+		cpu := int64(nodeUsage.cpu)
+		memory := int64(nodeUsage.memory) / 1024 / 1024 // MiB
+		detail.CPUUsage = &cpu
+		detail.MemoryUsage = &memory
+	}
+	for _, condition := range node.Status.Conditions {
+		detail.Conditions = append(detail.Conditions, metricsCondition(
+			string(condition.Type), string(condition.Status), condition.Reason, condition.Message, condition.LastTransitionTime,
+		))
+	}
+	for _, taint := range node.Spec.Taints {
+		nodeTaint := metrics.Taint{Key: taint.Key, Value: taint.Value, Effect: string(taint.Effect)}
+		if taint.TimeAdded != nil {
+			added := taint.TimeAdded.Time
+			nodeTaint.TimeAdded = &added
+		}
+		detail.Taints = append(detail.Taints, nodeTaint)
+	}
+	for _, address := range node.Status.Addresses {
+		detail.Addresses = append(detail.Addresses, metrics.NodeAddress{Type: string(address.Type), Address: address.Address})
+	}
+	for i := range pods.Items {
+		detail.Pods = append(detail.Pods, podMetrics(&pods.Items[i], usage[pods.Items[i].Namespace]))
+	}
+	sort.Slice(detail.Pods, func(i, j int) bool {
+		if detail.Pods[i].Namespace != detail.Pods[j].Namespace {
+			return detail.Pods[i].Namespace < detail.Pods[j].Namespace
+		}
+		return detail.Pods[i].PodName < detail.Pods[j].PodName
+	})
+
+	return detail, nil
+}
+
+// podsUsage fetches the usage of every namespace the pods are in, usage is keyed by namespace
+// since the metrics sources are queried per namespace
+func (c *KubernetesClient) podsUsage(ctx context.Context, pods []corev1.Pod) map[string]map[containerKey]resourceUsage {
+	namespaces := make(map[string]struct{})
+	for i := range pods {
+		namespaces[pods[i].Namespace] = struct{}{}
+	}
+
+	var mu sync.Mutex
+	result := make(map[string]map[containerKey]resourceUsage, len(namespaces))
+	group := errgroup.Group{}
+	group.SetLimit(maxConcurrentQueries)
+	for namespace := range namespaces {
+		group.Go(func() error {
+			usage := c.namespaceUsage(ctx, namespace)
+			mu.Lock()
+			result[namespace] = usage
+			mu.Unlock()
+			return nil
+		})
+	}
+	group.Wait()
+	return result
+}
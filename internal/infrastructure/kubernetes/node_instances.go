@@ -0,0 +1,159 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/metrics"
+	"main/internal/infrastructure/prometheus"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeInstancesRefresh is how long the mapping is used before nodes and instances are listed again
+const nodeInstancesRefresh = 5 * time.Minute
+
+// nodeInstanceResolver translates node names to the value of the node label of the queries.
+// When that label holds the scrape address, the instances of the resolver query are matched
+// to the nodes by their nodename label, or else by the node addresses.
+type nodeInstanceResolver struct {
+	clientset        kubernetes.Interface
+	prometheusClient prometheus.PrometheusClient
+	queries          *querySet
+
+	mu        sync.Mutex
+	instances map[string]string
+	nodes     map[string]string
+	refreshed time.Time
+}
+
+func newNodeInstanceResolver(clientset kubernetes.Interface, prometheusClient prometheus.PrometheusClient, queries *querySet) *nodeInstanceResolver {
+	return &nodeInstanceResolver{
+		clientset:        clientset,
+		prometheusClient: prometheusClient,
+		queries:          queries,
+	}
+}
+
+// instance returns the label value of the node
+func (r *nodeInstanceResolver) instance(ctx context.Context, node string) (string, error) {
+	if r.queries.nodeIdentity == nodeIdentityName {
+		return node, nil
+	}
+
+	instances, _, err := r.mapping(ctx)
+	if err != nil {
+		return "", err
+	}
+	instance, ok := instances[node]
+	if !ok {
+		return "", fmt.Errorf("%w: no Prometheus instance for node %s", metrics.ErrNotFound, node)
+	}
+	return instance, nil
+}
+
+// nodeNames returns the node name of each label value, values of unknown instances are left out
+func (r *nodeInstanceResolver) nodeNames(ctx context.Context, values []string) (map[string]string, error) {
+	result := make(map[string]string, len(values))
+	if r.queries.nodeIdentity == nodeIdentityName {
+		for _, value := range values {
+			result[value] = value
+		}
+		return result, nil
+	}
+
+	_, nodes, err := r.mapping(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		if node, ok := nodes[value]; ok {
+			result[value] = node
+		}
+	}
+	return result, nil
+}
+
+func (r *nodeInstanceResolver) mapping(ctx context.Context) (map[string]string, map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.instances != nil && time.Since(r.refreshed) < nodeInstancesRefresh {
+		return r.instances, r.nodes, nil
+	}
+
+	instances, err := r.resolve(ctx)
+	if err != nil {
+		// A stale mapping is better than none while Prometheus or the API server is unavailable
+		if r.instances != nil {
+			return r.instances, r.nodes, nil
+		}
+		return nil, nil, err
+	}
+
+	r.instances = instances
+	r.nodes = make(map[string]string, len(instances))
+	for node, instance := range instances {
+		r.nodes[instance] = node
+	}
+	r.refreshed = time.Now()
+	return r.instances, r.nodes, nil
+}
+
+func (r *nodeInstanceResolver) resolve(ctx context.Context) (map[string]string, error) {
+	nodeList, err := r.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	query, err := r.queries.render(queryGroupResolver, queryNodeInstances, queryData{})
+	if err != nil {
+		return nil, err
+	}
+	value, err := r.prometheusClient.GetMetricValueContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node instances: %w", err)
+	}
+
+	names := make(map[string]struct{}, len(nodeList.Items))
+	byAddress := make(map[string]string)
+	for _, node := range nodeList.Items {
+		names[node.Name] = struct{}{}
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP || address.Type == corev1.NodeExternalIP || address.Type == corev1.NodeHostName {
+				byAddress[address.Address] = node.Name
+			}
+		}
+	}
+
+	instanceLabel := model.LabelName(r.queries.label("node"))
+	nodenameLabel := model.LabelName(r.queries.label("nodename"))
+	instances := make(map[string]string)
+	vector, _ := value.(model.Vector)
+	for _, sample := range vector {
+		instance := string(sample.Metric[instanceLabel])
+		if instance == "" {
+			continue
+		}
+		if nodename := string(sample.Metric[nodenameLabel]); nodename != "" {
+			if _, ok := names[nodename]; ok {
+				instances[nodename] = instance
+				continue
+			}
+		}
+		host := instance
+		if splitHost, _, err := net.SplitHostPort(instance); err == nil {
+			host = splitHost
+		}
+		if node, ok := byAddress[host]; ok {
+			if _, resolved := instances[node]; !resolved {
+				instances[node] = instance
+			}
+		}
+	}
+	return instances, nil
+}
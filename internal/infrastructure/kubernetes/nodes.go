@@ -4,23 +4,29 @@ import (
 	"context"
 	"fmt"
 	"main/internal/domain/metrics"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/prometheus/common/model"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
 )
 
-func (c *KubernetesClient) GetNodes() ([]metrics.NodeMetrics, error) {
-	nodes, err := c.clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+const (
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+	legacyNodeRoleLabel = "kubernetes.io/role"
+)
+
+func (c *KubernetesClient) GetNodes(ctx context.Context) ([]metrics.NodeMetrics, error) {
+	nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 
 	// Try to get metrics, but don't fail if we can't
-	nodeMetrics, err := c.getNodeMetrics()
+	nodeMetrics, err := c.getNodeMetrics(ctx)
 	if err != nil {
 		c.logger.Errorf("Failed to get node metrics: %v", err)
 		// Return nodes without metrics
@@ -30,52 +36,28 @@ func (c *KubernetesClient) GetNodes() ([]metrics.NodeMetrics, error) {
 	return NodeFromMetrics(nodeMetrics, nodes.Items), nil
 }
 
-func (c *KubernetesClient) getNodeMetrics() ([]v1beta1.NodeMetrics, error) {
-	nodes, err := c.clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+func (c *KubernetesClient) getNodeMetrics(ctx context.Context) ([]v1beta1.NodeMetrics, error) {
+	metricsCtx, cancel := context.WithTimeout(ctx, podMetricsTimeout)
+	defer cancel()
+
+	usage, err := c.metrics.nodeUsage(metricsCtx)
 	if err != nil {
 		return nil, err
 	}
 
-	var nodeMetrics []v1beta1.NodeMetrics
-
-	for _, node := range nodes.Items {
-		// Получаем метрики CPU
-		cpuQuery := fmt.Sprintf(`sum(rate(node_cpu_seconds_total{node="%s", mode!="idle"}[5m])) * 1000`, node.Name)
-		cpuValue, err := c.prometheusClient.GetMetricValue(cpuQuery)
-		if err != nil {
-			c.logger.Errorf("failed to get CPU usage for node %s: %v", node.Name, err)
-			continue
-		}
-		cpuUsage := int64(0)
-		if vector, ok := cpuValue.(model.Vector); ok && len(vector) > 0 {
-			cpuUsage = int64(vector[0].Value)
-		}
-
-		// Получаем метрики памяти
-		memoryQuery := fmt.Sprintf(`node_memory_MemTotal_bytes{node="%s"} - node_memory_MemAvailable_bytes{node="%s"}`, node.Name, node.Name)
-		memoryValue, err := c.prometheusClient.GetMetricValue(memoryQuery)
-		if err != nil {
-			c.logger.Errorf("failed to get memory usage for node %s: %v", node.Name, err)
-			continue
-		}
-		memoryUsage := int64(0)
-		if vector, ok := memoryValue.(model.Vector); ok && len(vector) > 0 {
-			memoryUsage = int64(vector[0].Value)
-		}
-
-		// Создаем NodeMetrics
-		nodeMetric := v1beta1.NodeMetrics{
+	nodeMetrics := make([]v1beta1.NodeMetrics, 0, len(usage))
+	for name, nodeUsage := range usage {
+		nodeMetrics = append(nodeMetrics, v1beta1.NodeMetrics{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: node.Name,
+				Name: name,
 			},
 			Usage: corev1.ResourceList{
-				corev1.ResourceCPU:    *resource.NewMilliQuantity(cpuUsage, resource.DecimalSI),
-				corev1.ResourceMemory: *resource.NewQuantity(memoryUsage, resource.BinarySI),
+				corev1.ResourceCPU:    *resource.NewMilliQuantity(int64(nodeUsage.cpu), resource.DecimalSI),
+				corev1.ResourceMemory: *resource.NewQuantity(int64(nodeUsage.memory), resource.BinarySI),
 			},
 			Timestamp: metav1.Time{Time: time.Now()},
 			Window:    metav1.Duration{Duration: 5 * time.Minute},
-		}
-		nodeMetrics = append(nodeMetrics, nodeMetric)
+		})
 	}
 
 	return nodeMetrics, nil
@@ -130,14 +112,27 @@ func NodeFromMetrics(nodeMetrics []v1beta1.NodeMetrics, nodes []corev1.Node) []m
 	return result
 }
 
+// getNodeRoles derives the roles from the node-role.kubernetes.io/<role> labels and the
+// older kubernetes.io/role label some installers still set
 func getNodeRoles(node *corev1.Node) []string {
 	roles := []string{}
-	if _, exists := node.Labels["node-role.kubernetes.io/control-plane"]; exists {
-		roles = append(roles, "control-plane")
+	seen := make(map[string]struct{})
+	add := func(role string) {
+		if _, exists := seen[role]; role == "" || exists {
+			return
+		}
+		seen[role] = struct{}{}
+		roles = append(roles, role)
 	}
-	if _, exists := node.Labels["node-role.kubernetes.io/worker"]; exists {
-		roles = append(roles, "worker")
+
+	for label, value := range node.Labels {
+		if role, ok := strings.CutPrefix(label, nodeRoleLabelPrefix); ok {
+			add(role)
+		} else if label == legacyNodeRoleLabel {
+			add(value)
+		}
 	}
+	sort.Strings(roles)
 	return roles
 }
 
@@ -149,51 +144,3 @@ func getNodeStatus(node *corev1.Node) string {
 	}
 	return "NotReady"
 }
-
-type NodeHistoricalMetrics struct {
-	CPUUsage    []MetricPoint `json:"cpu_usage"`
-	MemoryUsage []MetricPoint `json:"memory_usage"`
-}
-
-func (c *KubernetesClient) GetNodeHistoricalMetrics(nodeName string, start, end time.Time, step time.Duration) (*NodeHistoricalMetrics, error) {
-	cpuQuery := fmt.Sprintf(`sum(rate(node_cpu_seconds_total{mode="user",node="%s"}[5m]))`, nodeName)
-	cpuValue, err := c.prometheusClient.GetMetricHistory(cpuQuery, start, end, step)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get CPU usage history: %v", err)
-	}
-
-	memoryQuery := fmt.Sprintf(`node_memory_MemTotal_bytes{node="%s"} - node_memory_MemAvailable_bytes{node="%s"}`, nodeName, nodeName)
-	memoryValue, err := c.prometheusClient.GetMetricHistory(memoryQuery, start, end, step)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get memory usage history: %v", err)
-	}
-
-	metrics := &NodeHistoricalMetrics{
-		CPUUsage:    make([]MetricPoint, 0),
-		MemoryUsage: make([]MetricPoint, 0),
-	}
-
-	if matrix, ok := cpuValue.(model.Matrix); ok {
-		for _, series := range matrix {
-			for _, point := range series.Values {
-				metrics.CPUUsage = append(metrics.CPUUsage, MetricPoint{
-					Timestamp: point.Timestamp.Time(),
-					Value:     float64(point.Value),
-				})
-			}
-		}
-	}
-
-	if matrix, ok := memoryValue.(model.Matrix); ok {
-		for _, series := range matrix {
-			for _, point := range series.Values {
-				metrics.MemoryUsage = append(metrics.MemoryUsage, MetricPoint{
-					Timestamp: point.Timestamp.Time(),
-					Value:     float64(point.Value),
-				})
-			}
-		}
-	}
-
-	return metrics, nil
-}
@@ -0,0 +1,162 @@
+package kubernetes
+
+import (
+	"context"
+	"main/internal/domain/events"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	ownerCacheTTL      = 10 * time.Minute
+	ownerCacheMaxSize  = 10000
+	ownerLookupTimeout = 5 * time.Second
+	// Pod -> ReplicaSet -> Deployment and Pod -> Job -> CronJob are the longest common chains
+	maxOwnerDepth = 4
+)
+
+type ownerCacheEntry struct {
+	owner   events.InvolvedObject
+	expires time.Time
+}
+
+// ownerResolver finds the top-level workload controlling an object by following
+// controller ownerReferences, results are cached since many events share an owner
+type ownerResolver struct {
+	clientset kubernetes.Interface
+	mu        sync.Mutex
+	cache     map[string]ownerCacheEntry
+}
+
+func newOwnerResolver(clientset kubernetes.Interface) *ownerResolver {
+	return &ownerResolver{
+		clientset: clientset,
+		cache:     make(map[string]ownerCacheEntry),
+	}
+}
+
+// resolve returns the owner of the object, references may be passed when the object
+// is already at hand. An empty owner is returned when the object can't be looked up, the
+// chain is only cached when it ended or an object of it is gone.
+func (r *ownerResolver) resolve(ctx context.Context, object events.InvolvedObject, references []metav1.OwnerReference) events.InvolvedObject {
+	if object.Kind == "" || object.Name == "" {
+		return events.InvolvedObject{}
+	}
+
+	key := object.Kind + "/" + object.Namespace + "/" + object.Name
+	if owner, ok := r.cached(key); ok {
+		return owner
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ownerLookupTimeout)
+	defer cancel()
+
+	owner := object
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		if references == nil {
+			var err error
+			references, err = r.ownerReferences(ctx, owner)
+			if err != nil {
+				if !apierrors.IsNotFound(err) {
+					// A failed lookup isn't cached, a later event may resolve the rest of the chain
+					if owner == object {
+						return events.InvolvedObject{}
+					}
+					return owner
+				}
+				if owner == object {
+					owner = events.InvolvedObject{}
+				}
+				break
+			}
+		}
+
+		controller := metav1.GetControllerOfNoCopy(&metav1.ObjectMeta{OwnerReferences: references})
+		if controller == nil {
+			break
+		}
+		owner = events.InvolvedObject{
+			Kind:       controller.Kind,
+			Name:       controller.Name,
+			Namespace:  object.Namespace,
+			UID:        string(controller.UID),
+			APIVersion: controller.APIVersion,
+		}
+		references = nil
+	}
+
+	r.store(key, owner)
+	return owner
+}
+
+// ResolveOwners sets the top-level owner of the events. Events already carrying an owner,
+// like the direct controller of a pod, are resolved from it. An event keeps its owner
+// when nothing could be looked up, ctx bounds the time spent on the whole batch.
+func (c KubernetesClient) ResolveOwners(ctx context.Context, list []events.Event) {
+	for i := range list {
+		object := list[i].Involved()
+		if list[i].OwnerKind != "" {
+			object = events.InvolvedObject{
+				Kind:      list[i].OwnerKind,
+				Name:      list[i].OwnerName,
+				Namespace: list[i].InvolvedNamespace,
+			}
+		}
+		if owner := c.owners.resolve(ctx, object, nil); owner.Kind != "" {
+			list[i].SetOwner(owner)
+		}
+	}
+}
+
+// ownerReferences fetches the references of the kinds that are usually controlled by another
+// workload, any other kind is treated as top-level
+func (r *ownerResolver) ownerReferences(ctx context.Context, object events.InvolvedObject) ([]metav1.OwnerReference, error) {
+	var meta metav1.Object
+	var err error
+	switch object.Kind {
+	case "Pod":
+		meta, err = r.clientset.CoreV1().Pods(object.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+	case "ReplicaSet":
+		meta, err = r.clientset.AppsV1().ReplicaSets(object.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+	case "Job":
+		meta, err = r.clientset.BatchV1().Jobs(object.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+	default:
+		return []metav1.OwnerReference{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return meta.GetOwnerReferences(), nil
+}
+
+func (r *ownerResolver) cached(key string) (events.InvolvedObject, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return events.InvolvedObject{}, false
+	}
+	return entry.owner, true
+}
+
+func (r *ownerResolver) store(key string, owner events.InvolvedObject) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if len(r.cache) >= ownerCacheMaxSize {
+		for cachedKey, entry := range r.cache {
+			if now.After(entry.expires) {
+				delete(r.cache, cachedKey)
+			}
+		}
+	}
+	if len(r.cache) < ownerCacheMaxSize {
+		r.cache[key] = ownerCacheEntry{owner: owner, expires: now.Add(ownerCacheTTL)}
+	}
+}
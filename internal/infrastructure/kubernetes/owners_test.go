@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"context"
+	"main/internal/domain/events"
+	"sync/atomic"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func controllerReference(kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: name, UID: types.UID("uid-" + name), Controller: &controller}}
+}
+
+func TestOwnerResolverCaching(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default", OwnerReferences: controllerReference("ReplicaSet", "api-7d9f")}}
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "api-7d9f", Namespace: "default", OwnerReferences: controllerReference("Deployment", "api")}}
+	object := events.InvolvedObject{Kind: "Pod", Name: "api-0", Namespace: "default"}
+	replicaSetOwner := events.InvolvedObject{Kind: "ReplicaSet", Name: "api-7d9f", Namespace: "default", UID: "uid-api-7d9f", APIVersion: "apps/v1"}
+	deploymentOwner := events.InvolvedObject{Kind: "Deployment", Name: "api", Namespace: "default", UID: "uid-api", APIVersion: "apps/v1"}
+
+	tests := []struct {
+		name        string
+		lookupError error
+		first       events.InvolvedObject
+		second      events.InvolvedObject
+	}{
+		{
+			name:   "resolved chain is cached",
+			first:  deploymentOwner,
+			second: deploymentOwner,
+		},
+		{
+			name:        "deleted owner is cached",
+			lookupError: apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "replicasets"}, "api-7d9f"),
+			first:       replicaSetOwner,
+			second:      replicaSetOwner,
+		},
+		{
+			name:        "unavailable API is not cached",
+			lookupError: apierrors.NewServiceUnavailable("etcd is unavailable"),
+			first:       replicaSetOwner,
+			second:      deploymentOwner,
+		},
+		{
+			name:        "timeout is not cached",
+			lookupError: context.DeadlineExceeded,
+			first:       replicaSetOwner,
+			second:      deploymentOwner,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewClientset(pod, replicaSet)
+			var failures atomic.Int32
+			if test.lookupError != nil {
+				failures.Store(1)
+			}
+			client.PrependReactor("get", "replicasets", func(k8stesting.Action) (bool, runtime.Object, error) {
+				if failures.Add(-1) >= 0 {
+					return true, nil, test.lookupError
+				}
+				return false, nil, nil
+			})
+			resolver := newOwnerResolver(client)
+
+			if owner := resolver.resolve(context.Background(), object, nil); owner != test.first {
+				t.Errorf("first owner = %+v, want %+v", owner, test.first)
+			}
+			if owner := resolver.resolve(context.Background(), object, nil); owner != test.second {
+				t.Errorf("second owner = %+v, want %+v", owner, test.second)
+			}
+		})
+	}
+}
+
+func TestOwnerResolverUnknownObject(t *testing.T) {
+	object := events.InvolvedObject{Kind: "Pod", Name: "api-0", Namespace: "default"}
+
+	tests := []struct {
+		name        string
+		lookupError error
+		cached      bool
+	}{
+		{name: "deleted object", lookupError: apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "api-0"), cached: true},
+		{name: "unavailable API", lookupError: apierrors.NewServiceUnavailable("etcd is unavailable"), cached: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewClientset()
+			client.PrependReactor("get", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, test.lookupError
+			})
+			resolver := newOwnerResolver(client)
+
+			if owner := resolver.resolve(context.Background(), object, nil); owner != (events.InvolvedObject{}) {
+				t.Errorf("owner = %+v, want none", owner)
+			}
+			if _, cached := resolver.cached("Pod/default/api-0"); cached != test.cached {
+				t.Errorf("cached = %v, want %v", cached, test.cached)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package kubernetes
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// podResources holds CPU in millicores and memory in bytes, a zero limit is unlimited
+type podResources struct {
+	cpuRequest    int64
+	cpuLimit      int64
+	memoryRequest int64
+	memoryLimit   int64
+}
+
+// effectivePodResources computes the resources the scheduler accounts for the pod:
+// the app containers and sidecars run together, every regular init container runs alone
+// next to the sidecars started before it, the larger of the two wins and the pod overhead
+// is added on top.
+func effectivePodResources(pod *corev1.Pod) podResources {
+	cpuRequest, _ := effectivePodQuantity(pod, corev1.ResourceCPU, false)
+	memoryRequest, _ := effectivePodQuantity(pod, corev1.ResourceMemory, false)
+	cpuLimit, cpuLimited := effectivePodQuantity(pod, corev1.ResourceCPU, true)
+	memoryLimit, memoryLimited := effectivePodQuantity(pod, corev1.ResourceMemory, true)
+
+	resources := podResources{
+		cpuRequest:    cpuRequest + resourceValue(pod.Spec.Overhead, corev1.ResourceCPU),
+		memoryRequest: memoryRequest + resourceValue(pod.Spec.Overhead, corev1.ResourceMemory),
+	}
+	if cpuLimited {
+		resources.cpuLimit = cpuLimit + resourceValue(pod.Spec.Overhead, corev1.ResourceCPU)
+	}
+	if memoryLimited {
+		resources.memoryLimit = memoryLimit + resourceValue(pod.Spec.Overhead, corev1.ResourceMemory)
+	}
+	return resources
+}
+
+// effectivePodQuantity returns the pod request or limit of the resource. For limits it also
+// reports whether the pod is limited at all, which isn't the case as soon as one of the
+// long-running containers has no limit.
+func effectivePodQuantity(pod *corev1.Pod, name corev1.ResourceName, limits bool) (int64, bool) {
+	quantity := func(container corev1.Container) (int64, bool) {
+		list := container.Resources.Requests
+		if limits {
+			list = container.Resources.Limits
+		}
+		if _, ok := list[name]; !ok {
+			return 0, false
+		}
+		return resourceValue(list, name), true
+	}
+
+	limited := true
+	running := int64(0)
+	for _, container := range pod.Spec.Containers {
+		value, ok := quantity(container)
+		limited = limited && ok
+		running += value
+	}
+
+	sidecars := int64(0)
+	initPeak := int64(0)
+	for _, container := range pod.Spec.InitContainers {
+		value, ok := quantity(container)
+		if isSidecar(container) {
+			limited = limited && ok
+			running += value
+			sidecars += value
+			initPeak = max(initPeak, sidecars)
+			continue
+		}
+		initPeak = max(initPeak, value+sidecars)
+	}
+
+	return max(running, initPeak), limited
+}
+
+// resourceValue returns millicores for CPU and the plain value for any other resource
+func resourceValue(list corev1.ResourceList, name corev1.ResourceName) int64 {
+	value, ok := list[name]
+	if !ok {
+		return 0
+	}
+	if name == corev1.ResourceCPU {
+		return value.MilliValue()
+	}
+	return value.Value()
+}
+
+// isSidecar reports whether the init container keeps running next to the app containers
+func isSidecar(container corev1.Container) bool {
+	return container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
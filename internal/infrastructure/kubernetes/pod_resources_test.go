@@ -0,0 +1,199 @@
+package kubernetes
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const mebibyte = 1024 * 1024
+
+// testContainer takes requests and limits as "cpu", "memory" pairs, an empty value isn't set
+func testContainer(requestCPU, requestMemory, limitCPU, limitMemory string) corev1.Container {
+	list := func(cpu, memory string) corev1.ResourceList {
+		result := corev1.ResourceList{}
+		if cpu != "" {
+			result[corev1.ResourceCPU] = resource.MustParse(cpu)
+		}
+		if memory != "" {
+			result[corev1.ResourceMemory] = resource.MustParse(memory)
+		}
+		return result
+	}
+	return corev1.Container{
+		Resources: corev1.ResourceRequirements{
+			Requests: list(requestCPU, requestMemory),
+			Limits:   list(limitCPU, limitMemory),
+		},
+	}
+}
+
+func testSidecar(requestCPU, requestMemory, limitCPU, limitMemory string) corev1.Container {
+	container := testContainer(requestCPU, requestMemory, limitCPU, limitMemory)
+	always := corev1.ContainerRestartPolicyAlways
+	container.RestartPolicy = &always
+	return container
+}
+
+func TestEffectivePodResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     corev1.PodSpec
+		expected podResources
+	}{
+		{
+			name: "plain containers are summed",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+					testContainer("50m", "64Mi", "100m", "128Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 150, memoryRequest: 192 * mebibyte, cpuLimit: 300, memoryLimit: 384 * mebibyte},
+		},
+		{
+			name: "large classic init container wins over the app containers",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					testContainer("1", "1Gi", "2", "2Gi"),
+				},
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 1000, memoryRequest: 1024 * mebibyte, cpuLimit: 2000, memoryLimit: 2048 * mebibyte},
+		},
+		{
+			name: "small classic init container is ignored",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					testContainer("10m", "16Mi", "20m", "32Mi"),
+				},
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 100, memoryRequest: 128 * mebibyte, cpuLimit: 200, memoryLimit: 256 * mebibyte},
+		},
+		{
+			name: "sidecar before an init container runs next to it",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					testSidecar("50m", "64Mi", "100m", "128Mi"),
+					testContainer("500m", "512Mi", "1", "1Gi"),
+				},
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 550, memoryRequest: 576 * mebibyte, cpuLimit: 1100, memoryLimit: 1152 * mebibyte},
+		},
+		{
+			name: "sidecar after an init container doesn't add to it",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					testContainer("500m", "512Mi", "1", "1Gi"),
+					testSidecar("50m", "64Mi", "100m", "128Mi"),
+				},
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 500, memoryRequest: 512 * mebibyte, cpuLimit: 1000, memoryLimit: 1024 * mebibyte},
+		},
+		{
+			name: "sidecars are added to the app containers",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					testSidecar("50m", "64Mi", "100m", "128Mi"),
+					testContainer("10m", "16Mi", "20m", "32Mi"),
+					testSidecar("25m", "32Mi", "50m", "64Mi"),
+				},
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 175, memoryRequest: 224 * mebibyte, cpuLimit: 350, memoryLimit: 448 * mebibyte},
+		},
+		{
+			name: "overhead is added to requests and limits",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+				},
+				Overhead: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("120Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 350, memoryRequest: 248 * mebibyte, cpuLimit: 450, memoryLimit: 376 * mebibyte},
+		},
+		{
+			name: "overhead isn't added to missing limits",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "", ""),
+				},
+				Overhead: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("120Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 350, memoryRequest: 248 * mebibyte},
+		},
+		{
+			name: "one app container without limits makes the pod unlimited",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+					testContainer("100m", "128Mi", "", "256Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 200, memoryRequest: 256 * mebibyte, memoryLimit: 512 * mebibyte},
+		},
+		{
+			name: "sidecar without limits makes the pod unlimited",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					testSidecar("50m", "64Mi", "", ""),
+				},
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 150, memoryRequest: 192 * mebibyte},
+		},
+		{
+			name: "classic init container without limits keeps the pod limited",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					testContainer("10m", "16Mi", "", ""),
+				},
+				Containers: []corev1.Container{
+					testContainer("100m", "128Mi", "200m", "256Mi"),
+				},
+			},
+			expected: podResources{cpuRequest: 100, memoryRequest: 128 * mebibyte, cpuLimit: 200, memoryLimit: 256 * mebibyte},
+		},
+		{
+			name: "missing requests count as zero",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					testContainer("", "", "200m", "256Mi"),
+				},
+			},
+			expected: podResources{cpuLimit: 200, memoryLimit: 256 * mebibyte},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resources := effectivePodResources(&corev1.Pod{Spec: test.spec})
+			if resources != test.expected {
+				t.Errorf("effectivePodResources() = %+v, want %+v", resources, test.expected)
+			}
+		})
+	}
+}
@@ -2,175 +2,168 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 	"main/internal/domain/metrics"
 	"time"
 
-	"github.com/prometheus/common/model"
+	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func (c *KubernetesClient) GetPods(namespace string) ([]metrics.PodMetrics, error) {
-	pods, err := c.clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
-	if err != nil {
+const (
+	// podMetricsTimeout bounds the usage queries of a single pod list request
+	podMetricsTimeout = 10 * time.Second
+	// maxConcurrentQueries limits the Prometheus queries a single request runs at once
+	maxConcurrentQueries = 4
+)
+
+// GetPods lists the pods of the namespace with their usage. Usage of the whole namespace is
+// fetched from the metrics source at once and joined by pod and container name, pods are
+// still returned with zero usage when no source is available.
+func (c *KubernetesClient) GetPods(ctx context.Context, namespace string) ([]metrics.PodMetrics, error) {
+	var pods *corev1.PodList
+	var usage map[containerKey]resourceUsage
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		pods, err = c.clientset.CoreV1().Pods(namespace).List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		usage = c.namespaceUsage(groupCtx, namespace)
+		return nil
+	})
+	if err := group.Wait(); err != nil {
 		return nil, err
 	}
 
 	result := make([]metrics.PodMetrics, 0, len(pods.Items))
-	for _, pod := range pods.Items {
-		// Initialize with zero values
-		cpuUsage := int64(0)
-		memoryUsage := int64(0)
-		var cpuUsagePercent *float64
-		var memoryUsagePercent *float64
-
-		// Try to get CPU usage, but continue if it fails
-		cpuUsage, err = c.getPodCPUUsage(namespace, pod.Name)
-		if err != nil {
-			c.logger.Errorf("failed to get CPU usage for pod %s: %v", pod.Name, err)
-		}
-
-		// Try to get memory usage, but continue if it fails
-		memoryUsage, err = c.getPodMemoryUsage(namespace, pod.Name)
-		if err != nil {
-			c.logger.Errorf("failed to get memory usage for pod %s: %v", pod.Name, err)
-		}
-
-		// Get resources from pod.Spec
-		cpuRequest := int64(0)
-		cpuLimit := int64(0)
-		memoryRequest := int64(0)
-		memoryLimit := int64(0)
-
-		if len(pod.Spec.Containers) > 0 {
-			if pod.Spec.Containers[0].Resources.Requests.Cpu() != nil {
-				cpuRequest = pod.Spec.Containers[0].Resources.Requests.Cpu().MilliValue()
-			}
-			if pod.Spec.Containers[0].Resources.Limits.Cpu() != nil {
-				cpuLimit = pod.Spec.Containers[0].Resources.Limits.Cpu().MilliValue()
-			}
-			if pod.Spec.Containers[0].Resources.Requests.Memory() != nil {
-				memoryRequest = pod.Spec.Containers[0].Resources.Requests.Memory().Value() / 1024 / 1024 // MiB
-			}
-			if pod.Spec.Containers[0].Resources.Limits.Memory() != nil {
-				memoryLimit = pod.Spec.Containers[0].Resources.Limits.Memory().Value() / 1024 / 1024 // MiB
-			}
-		}
-
-		// Calculate percentages if we have limits
-		if cpuLimit > 0 {
-			percent := float64(cpuUsage) / float64(cpuLimit) * 100
-			cpuUsagePercent = &percent
-		}
-
-		if memoryLimit > 0 {
-			percent := float64(memoryUsage) / float64(memoryLimit) * 100
-			memoryUsagePercent = &percent
-		}
-
-		restartCount := int32(0)
-		if len(pod.Status.ContainerStatuses) > 0 {
-			restartCount = pod.Status.ContainerStatuses[0].RestartCount
-		}
-
-		startTime := ""
-		if pod.Status.StartTime != nil {
-			startTime = pod.Status.StartTime.Format(time.RFC3339)
-		}
-
-		result = append(result, metrics.PodMetrics{
-			PodName:            pod.Name,
-			Namespace:          pod.Namespace,
-			NodeName:           pod.Spec.NodeName,
-			Status:             string(pod.Status.Phase),
-			StartTime:          startTime,
-			CPUUsage:           cpuUsage,
-			CPUUsagePercent:    cpuUsagePercent,
-			CPUUsageLimit:      cpuLimit,
-			CPUUsageRequest:    cpuRequest,
-			MemoryUsage:        memoryUsage,
-			MemoryUsagePercent: memoryUsagePercent,
-			MemoryUsageLimit:   memoryLimit,
-			MemoryUsageRequest: memoryRequest,
-			RestartCount:       restartCount,
-		})
+	for i := range pods.Items {
+		result = append(result, podMetrics(&pods.Items[i], usage))
 	}
 	return result, nil
 }
 
-func (c *KubernetesClient) getPodCPUUsage(namespace, podName string) (int64, error) {
-	query := fmt.Sprintf(`sum(rate(container_cpu_usage_seconds_total{namespace="%s", pod="%s"}[5m])) * 1000`, namespace, podName)
-	value, err := c.prometheusClient.GetMetricValue(query)
-	if err != nil {
-		return 0, err
-	}
-	if vector, ok := value.(model.Vector); ok && len(vector) > 0 {
-		return int64(vector[0].Value), nil
-	}
-	return 0, fmt.Errorf("no CPU usage data for pod %s", podName)
-}
+// namespaceUsage returns the usage of the containers in the namespace, it's nil when no
+// metrics source could be reached so callers still return their objects
+func (c *KubernetesClient) namespaceUsage(ctx context.Context, namespace string) map[containerKey]resourceUsage {
+	ctx, cancel := context.WithTimeout(ctx, podMetricsTimeout)
+	defer cancel()
 
-func (c *KubernetesClient) getPodMemoryUsage(namespace, podName string) (int64, error) {
-	query := fmt.Sprintf(`container_memory_working_set_bytes{namespace="%s", pod="%s"}`, namespace, podName)
-	value, err := c.prometheusClient.GetMetricValue(query)
+	usage, err := c.metrics.podUsage(ctx, namespace)
 	if err != nil {
-		return 0, err
+		c.logger.Errorf("failed to get pod usage in namespace %s: %v", namespace, err)
+		return nil
 	}
-	if vector, ok := value.(model.Vector); ok && len(vector) > 0 {
-		return int64(vector[0].Value) / 1024 / 1024, nil // Переводим в MiB
+	if usage == nil {
+		usage = map[containerKey]resourceUsage{}
 	}
-	return 0, fmt.Errorf("no memory usage data for pod %s", podName)
+	return usage
 }
 
-type MetricPoint struct {
-	Timestamp time.Time `json:"timestamp"`
-	Value     float64   `json:"value"`
-}
+func podMetrics(pod *corev1.Pod, usage map[containerKey]resourceUsage) metrics.PodMetrics {
+	containers := podContainerMetrics(pod, usage)
 
-type PodHistoricalMetrics struct {
-	CPUUsage    []MetricPoint `json:"cpu_usage"`
-	MemoryUsage []MetricPoint `json:"memory_usage"`
-}
+	cpuUsage := int64(0)
+	memoryUsage := int64(0)
+	restartCount := int32(0)
+	for _, container := range containers {
+		cpuUsage += container.CPUUsage
+		memoryUsage += container.MemoryUsage
+		restartCount += container.RestartCount
+	}
 
-func (c *KubernetesClient) GetPodHistoricalMetrics(namespace, podName string, start, end time.Time, step time.Duration) (*PodHistoricalMetrics, error) {
-	cpuQuery := fmt.Sprintf(`sum(rate(container_cpu_usage_seconds_total{namespace="%s", pod="%s"}[5m]))`, namespace, podName)
-	cpuValue, err := c.prometheusClient.GetMetricHistory(cpuQuery, start, end, step)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get CPU usage history: %v", err)
+	resources := effectivePodResources(pod)
+	cpuLimit := resources.cpuLimit
+	memoryLimit := resources.memoryLimit / 1024 / 1024 // MiB
+
+	var cpuUsagePercent *float64
+	var memoryUsagePercent *float64
+	if cpuLimit > 0 {
+		percent := float64(cpuUsage) / float64(cpuLimit) * 100
+		cpuUsagePercent = &percent
+	}
+	if memoryLimit > 0 {
+		percent := float64(memoryUsage) / float64(memoryLimit) * 100
+		memoryUsagePercent = &percent
 	}
 
-	memoryQuery := fmt.Sprintf(`sum(container_memory_working_set_bytes{namespace="%s", pod="%s"})`, namespace, podName)
-	memoryValue, err := c.prometheusClient.GetMetricHistory(memoryQuery, start, end, step)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get memory usage history: %v", err)
+	startTime := ""
+	if pod.Status.StartTime != nil {
+		startTime = pod.Status.StartTime.Format(time.RFC3339)
 	}
 
-	metrics := &PodHistoricalMetrics{
-		CPUUsage:    make([]MetricPoint, 0),
-		MemoryUsage: make([]MetricPoint, 0),
+	return metrics.PodMetrics{
+		PodName:            pod.Name,
+		Namespace:          pod.Namespace,
+		NodeName:           pod.Spec.NodeName,
+		Status:             string(pod.Status.Phase),
+		StartTime:          startTime,
+		CPUUsage:           cpuUsage,
+		CPUUsagePercent:    cpuUsagePercent,
+		CPUUsageLimit:      cpuLimit,
+		CPUUsageRequest:    resources.cpuRequest,
+		MemoryUsage:        memoryUsage,
+		MemoryUsagePercent: memoryUsagePercent,
+		MemoryUsageLimit:   memoryLimit,
+		MemoryUsageRequest: resources.memoryRequest / 1024 / 1024, // MiB
+		RestartCount:       restartCount,
+		Containers:         containers,
 	}
+}
+
+// podContainerMetrics lists init containers first, in the order they are started
+func podContainerMetrics(pod *corev1.Pod, usage map[containerKey]resourceUsage) []metrics.ContainerMetrics {
+	containers := make([]metrics.ContainerMetrics, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	add := func(container corev1.Container, containerType string) {
+		containerUsage := usage[containerKey{pod: pod.Name, container: container.Name}]
+		containerMetrics := metrics.ContainerMetrics{
+			Name:               container.Name,
+			Type:               containerType,
+			CPUUsage:           int64(containerUsage.cpu),
+			CPUUsageLimit:      resourceValue(container.Resources.Limits, corev1.ResourceCPU),
+			CPUUsageRequest:    resourceValue(container.Resources.Requests, corev1.ResourceCPU),
+			MemoryUsage:        int64(containerUsage.memory) / 1024 / 1024,                                       // MiB
+			MemoryUsageLimit:   resourceValue(container.Resources.Limits, corev1.ResourceMemory) / 1024 / 1024,   // MiB
+			MemoryUsageRequest: resourceValue(container.Resources.Requests, corev1.ResourceMemory) / 1024 / 1024, // MiB
+			State:              "waiting",
+		}
 
-	if matrix, ok := cpuValue.(model.Matrix); ok {
-		for _, series := range matrix {
-			for _, point := range series.Values {
-				metrics.CPUUsage = append(metrics.CPUUsage, MetricPoint{
-					Timestamp: point.Timestamp.Time(),
-					Value:     float64(point.Value),
-				})
+		if status := findContainerStatus(pod, container.Name); status != nil {
+			containerMetrics.RestartCount = status.RestartCount
+			containerMetrics.Ready = status.Ready
+			switch {
+			case status.State.Running != nil:
+				containerMetrics.State = "running"
+			case status.State.Terminated != nil:
+				containerMetrics.State = "terminated"
+				containerMetrics.Reason = status.State.Terminated.Reason
+			case status.State.Waiting != nil:
+				containerMetrics.Reason = status.State.Waiting.Reason
+			}
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				containerMetrics.LastTerminationReason = terminated.Reason
 			}
 		}
+
+		containers = append(containers, containerMetrics)
 	}
 
-	if matrix, ok := memoryValue.(model.Matrix); ok {
-		for _, series := range matrix {
-			for _, point := range series.Values {
-				metrics.MemoryUsage = append(metrics.MemoryUsage, MetricPoint{
-					Timestamp: point.Timestamp.Time(),
-					Value:     float64(point.Value),
-				})
-			}
+	for _, container := range pod.Spec.InitContainers {
+		if isSidecar(container) {
+			add(container, metrics.ContainerTypeSidecar)
+		} else {
+			add(container, metrics.ContainerTypeInit)
 		}
 	}
+	for _, container := range pod.Spec.Containers {
+		add(container, metrics.ContainerTypeApp)
+	}
+	return containers
+}
 
-	return metrics, nil
+type containerKey struct {
+	pod       string
+	container string
 }
@@ -0,0 +1,238 @@
+package kubernetes
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	QueryPresetKubePrometheusStack = "kube-prometheus-stack"
+	QueryPresetPlain               = "plain"
+)
+
+const (
+	queryGroupUsage    = "usage"
+	queryGroupPod      = "pod"
+	queryGroupNode     = "node"
+	queryGroupResolver = "resolver"
+
+	queryPodCPU        = "pod_cpu"
+	queryPodMemory     = "pod_memory"
+	queryNodeCPU       = "node_cpu"
+	queryNodeMemory    = "node_memory"
+	queryNodeInstances = "node_instances"
+
+	// nodeIdentityName means the node label holds the node name
+	nodeIdentityName = "name"
+	// nodeIdentityInstance means the node label holds the scrape address which has to be resolved
+	nodeIdentityInstance = "instance"
+
+	// maxPresetDepth bounds the chain of query files extending presets
+	maxPresetDepth = 4
+)
+
+//go:embed presets/*.yaml
+var queryPresets embed.FS
+
+// querySetFile is the format of the presets and of PROMETHEUS_QUERY_FILE. A file extending a
+// preset only lists what differs, labels and queries are merged by name.
+type querySetFile struct {
+	Preset       string                       `json:"preset,omitempty"`
+	Labels       map[string]string            `json:"labels"`
+	NodeIdentity string                       `json:"node_identity"`
+	Queries      map[string]map[string]string `json:"queries"`
+}
+
+// querySet holds the parsed PromQL templates of a Prometheus setup
+type querySet struct {
+	labels       map[string]string
+	nodeIdentity string
+	templates    map[string]map[string]*template.Template
+}
+
+type queryData struct {
+	Namespace string
+	Pod       string
+	Node      string
+}
+
+// loadQuerySet reads the query file when it's set, otherwise the preset is used as it is
+func loadQuerySet(preset, path string) (*querySet, error) {
+	file := &querySetFile{Preset: preset}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read query file: %w", err)
+		}
+		file = &querySetFile{}
+		if err := yaml.UnmarshalStrict(data, file); err != nil {
+			return nil, fmt.Errorf("invalid query file %s: %w", path, err)
+		}
+	}
+
+	merged, err := mergeQueryPresets(file, 0)
+	if err != nil {
+		return nil, err
+	}
+	return newQuerySet(merged)
+}
+
+func mergeQueryPresets(file *querySetFile, depth int) (*querySetFile, error) {
+	if file.Preset == "" {
+		return file, nil
+	}
+	if depth >= maxPresetDepth {
+		return nil, fmt.Errorf("query presets extend each other more than %d times", maxPresetDepth)
+	}
+
+	data, err := queryPresets.ReadFile("presets/" + file.Preset + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown query preset %q", file.Preset)
+	}
+	base := &querySetFile{}
+	if err := yaml.UnmarshalStrict(data, base); err != nil {
+		return nil, fmt.Errorf("invalid query preset %s: %w", file.Preset, err)
+	}
+	base, err = mergeQueryPresets(base, depth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &querySetFile{
+		Labels:       make(map[string]string),
+		NodeIdentity: base.NodeIdentity,
+		Queries:      make(map[string]map[string]string),
+	}
+	for _, source := range []*querySetFile{base, file} {
+		for name, label := range source.Labels {
+			merged.Labels[name] = label
+		}
+		for group, queries := range source.Queries {
+			if merged.Queries[group] == nil {
+				merged.Queries[group] = make(map[string]string)
+			}
+			for name, query := range queries {
+				merged.Queries[group][name] = query
+			}
+		}
+	}
+	if file.NodeIdentity != "" {
+		merged.NodeIdentity = file.NodeIdentity
+	}
+	return merged, nil
+}
+
+func newQuerySet(file *querySetFile) (*querySet, error) {
+	set := &querySet{
+		labels:       file.Labels,
+		nodeIdentity: file.NodeIdentity,
+		templates:    make(map[string]map[string]*template.Template, len(file.Queries)),
+	}
+	if set.nodeIdentity == "" {
+		set.nodeIdentity = nodeIdentityName
+	}
+	if set.nodeIdentity != nodeIdentityName && set.nodeIdentity != nodeIdentityInstance {
+		return nil, fmt.Errorf("node_identity must be %s or %s", nodeIdentityName, nodeIdentityInstance)
+	}
+
+	funcs := template.FuncMap{
+		"quote": strconv.Quote,
+		"label": func(name string) (string, error) {
+			label, ok := set.labels[name]
+			if !ok {
+				return "", fmt.Errorf("no label mapping for %q", name)
+			}
+			return label, nil
+		},
+	}
+	var errs []error
+	for group, queries := range file.Queries {
+		switch group {
+		case queryGroupUsage, queryGroupPod, queryGroupNode, queryGroupResolver:
+		default:
+			errs = append(errs, fmt.Errorf("unknown query group %q", group))
+			continue
+		}
+
+		set.templates[group] = make(map[string]*template.Template, len(queries))
+		for name, query := range queries {
+			parsed, err := template.New(group + "." + name).Funcs(funcs).Parse(query)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			set.templates[group][name] = parsed
+			// Rendering once catches unknown labels and fields before the first request
+			if _, err := set.render(group, name, queryData{Namespace: "namespace", Pod: "pod", Node: "node"}); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	required := map[string][]string{
+		queryGroupUsage: {queryPodCPU, queryPodMemory, queryNodeCPU, queryNodeMemory},
+		queryGroupPod:   {"cpu_usage", "memory_usage"},
+		queryGroupNode:  {"cpu_usage", "memory_usage"},
+	}
+	if set.nodeIdentity == nodeIdentityInstance {
+		required[queryGroupResolver] = []string{queryNodeInstances}
+		if _, ok := set.labels["nodename"]; !ok {
+			errs = append(errs, errors.New(`the "nodename" label is required to resolve instances`))
+		}
+	}
+	for group, names := range required {
+		for _, name := range names {
+			if !set.has(group, name) {
+				errs = append(errs, fmt.Errorf("query %s.%s is missing", group, name))
+			}
+		}
+	}
+	for _, label := range []string{"namespace", "pod", "container", "node"} {
+		if _, ok := set.labels[label]; !ok {
+			errs = append(errs, fmt.Errorf("label %q is missing", label))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid Prometheus queries: %w", err)
+	}
+	return set, nil
+}
+
+func (s *querySet) has(group, name string) bool {
+	_, ok := s.templates[group][name]
+	return ok
+}
+
+func (s *querySet) render(group, name string, data queryData) (string, error) {
+	query, ok := s.templates[group][name]
+	if !ok {
+		return "", fmt.Errorf("query %s.%s is not defined", group, name)
+	}
+	var result strings.Builder
+	if err := query.Execute(&result, data); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+func (s *querySet) label(name string) string {
+	return s.labels[name]
+}
+
+func (s *querySet) names(group string) []string {
+	names := make([]string, 0, len(s.templates[group]))
+	for name := range s.templates[group] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,378 @@
+package kubernetes
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeQueryFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "queries.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write query file: %v", err)
+	}
+	return path
+}
+
+func TestEmbeddedPresetsRender(t *testing.T) {
+	presets, err := fs.Glob(queryPresets, "presets/*.yaml")
+	if err != nil {
+		t.Fatalf("failed to list presets: %v", err)
+	}
+	if len(presets) == 0 {
+		t.Fatal("no presets are embedded")
+	}
+
+	for _, path := range presets {
+		preset := strings.TrimSuffix(filepath.Base(path), ".yaml")
+		t.Run(preset, func(t *testing.T) {
+			set, err := loadQuerySet(preset, "")
+			if err != nil {
+				t.Fatalf("failed to load preset: %v", err)
+			}
+			data := queryData{Namespace: "default", Pod: "api-0", Node: "node-1"}
+			for _, group := range []string{queryGroupUsage, queryGroupPod, queryGroupNode, queryGroupResolver} {
+				for _, name := range set.names(group) {
+					query, err := set.render(group, name, data)
+					if err != nil {
+						t.Errorf("failed to render %s.%s: %v", group, name, err)
+						continue
+					}
+					if strings.Contains(query, "{{") || strings.Contains(query, "<no value>") {
+						t.Errorf("%s.%s is not fully rendered: %s", group, name, query)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLoadQuerySetPreset(t *testing.T) {
+	tests := []struct {
+		name         string
+		preset       string
+		nodeIdentity string
+		nodeLabel    string
+		resolver     bool
+	}{
+		{name: "kube-prometheus-stack", preset: QueryPresetKubePrometheusStack, nodeIdentity: nodeIdentityName, nodeLabel: "instance"},
+		{name: "plain", preset: QueryPresetPlain, nodeIdentity: nodeIdentityInstance, nodeLabel: "instance", resolver: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set, err := loadQuerySet(test.preset, "")
+			if err != nil {
+				t.Fatalf("failed to load preset: %v", err)
+			}
+			if set.nodeIdentity != test.nodeIdentity {
+				t.Errorf("nodeIdentity = %s, want %s", set.nodeIdentity, test.nodeIdentity)
+			}
+			if label := set.label("node"); label != test.nodeLabel {
+				t.Errorf("node label = %s, want %s", label, test.nodeLabel)
+			}
+			if has := set.has(queryGroupResolver, queryNodeInstances); has != test.resolver {
+				t.Errorf("has resolver query = %v, want %v", has, test.resolver)
+			}
+			for _, name := range []string{queryPodCPU, queryPodMemory, queryNodeCPU, queryNodeMemory} {
+				if !set.has(queryGroupUsage, name) {
+					t.Errorf("usage.%s is missing", name)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadQuerySetRejectsUnknownPreset(t *testing.T) {
+	if _, err := loadQuerySet("victoria-metrics", ""); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
+
+func TestLoadQuerySetMergesFileOverPreset(t *testing.T) {
+	path := writeQueryFile(t, `
+preset: kube-prometheus-stack
+labels:
+  namespace: kubernetes_namespace
+queries:
+  pod:
+    cpu_usage: sum(rate(custom_cpu{ {{label "namespace"}}={{quote .Namespace}}, {{label "pod"}}={{quote .Pod}}}[1m]))
+    gpu_usage: sum(gpu_utilization{ {{label "pod"}}={{quote .Pod}}})
+`)
+
+	// The preset of the file wins over the configured one
+	set, err := loadQuerySet(QueryPresetPlain, path)
+	if err != nil {
+		t.Fatalf("failed to load query file: %v", err)
+	}
+
+	if set.nodeIdentity != nodeIdentityName {
+		t.Errorf("nodeIdentity = %s, want %s from the preset", set.nodeIdentity, nodeIdentityName)
+	}
+	if label := set.label("namespace"); label != "kubernetes_namespace" {
+		t.Errorf("namespace label = %s, want kubernetes_namespace", label)
+	}
+	if label := set.label("pod"); label != "pod" {
+		t.Errorf("pod label = %s, want pod from the preset", label)
+	}
+
+	data := queryData{Namespace: "default", Pod: "api-0"}
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "cpu_usage", expected: `sum(rate(custom_cpu{ kubernetes_namespace="default", pod="api-0"}[1m]))`},
+		{name: "gpu_usage", expected: `sum(gpu_utilization{ pod="api-0"})`},
+		{name: "memory_usage", expected: `sum(container_memory_working_set_bytes{ kubernetes_namespace="default", pod="api-0"})`},
+	}
+	for _, test := range tests {
+		query, err := set.render(queryGroupPod, test.name, data)
+		if err != nil {
+			t.Errorf("failed to render pod.%s: %v", test.name, err)
+			continue
+		}
+		if query != test.expected {
+			t.Errorf("pod.%s = %s, want %s", test.name, query, test.expected)
+		}
+	}
+}
+
+func TestLoadQuerySetFileWithoutPreset(t *testing.T) {
+	path := writeQueryFile(t, `
+labels:
+  namespace: ns
+  pod: pod_name
+  container: container_name
+  node: node
+queries:
+  usage:
+    pod_cpu: pod_cpu{ {{label "namespace"}}={{quote .Namespace}}}
+    pod_memory: pod_memory{ {{label "namespace"}}={{quote .Namespace}}}
+    node_cpu: node_cpu
+    node_memory: node_memory
+  pod:
+    cpu_usage: cpu{ {{label "pod"}}={{quote .Pod}}}
+    memory_usage: memory{ {{label "pod"}}={{quote .Pod}}}
+  node:
+    cpu_usage: cpu{ {{label "node"}}={{quote .Node}}}
+    memory_usage: memory{ {{label "node"}}={{quote .Node}}}
+`)
+
+	set, err := loadQuerySet(QueryPresetKubePrometheusStack, path)
+	if err != nil {
+		t.Fatalf("failed to load query file: %v", err)
+	}
+	if names := set.names(queryGroupPod); !reflect.DeepEqual(names, []string{"cpu_usage", "memory_usage"}) {
+		t.Errorf("pod queries = %v, want only the ones of the file", names)
+	}
+	if set.nodeIdentity != nodeIdentityName {
+		t.Errorf("nodeIdentity = %s, want %s", set.nodeIdentity, nodeIdentityName)
+	}
+}
+
+func TestLoadQuerySetRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{
+			name: "unknown group",
+			content: `
+preset: kube-prometheus-stack
+queries:
+  cluster:
+    cpu_usage: sum(node_cpu)
+`,
+			message: `unknown query group "cluster"`,
+		},
+		{
+			name: "unknown field",
+			content: `
+preset: kube-prometheus-stack
+query:
+  pod:
+    cpu_usage: sum(cpu)
+`,
+			message: "invalid query file",
+		},
+		{
+			name: "unknown label",
+			content: `
+preset: kube-prometheus-stack
+queries:
+  pod:
+    cpu_usage: sum(cpu{ {{label "workload"}}={{quote .Pod}}})
+`,
+			message: `no label mapping for "workload"`,
+		},
+		{
+			name: "unknown field of the request",
+			content: `
+preset: kube-prometheus-stack
+queries:
+  pod:
+    cpu_usage: sum(cpu{ pod={{quote .Deployment}}})
+`,
+			message: "Deployment",
+		},
+		{
+			name: "invalid template",
+			content: `
+preset: kube-prometheus-stack
+queries:
+  pod:
+    cpu_usage: sum(cpu{ pod={{quote .Pod})
+`,
+			message: "pod.cpu_usage",
+		},
+		{
+			name: "unknown preset",
+			content: `
+preset: thanos
+`,
+			message: `unknown query preset "thanos"`,
+		},
+		{
+			name: "unknown node identity",
+			content: `
+preset: kube-prometheus-stack
+node_identity: hostname
+`,
+			message: "node_identity must be",
+		},
+		{
+			name: "instance identity without resolver",
+			content: `
+preset: kube-prometheus-stack
+node_identity: instance
+`,
+			message: "query resolver.node_instances is missing",
+		},
+		{
+			name: "missing required query",
+			content: `
+labels:
+  namespace: namespace
+  pod: pod
+  container: container
+  node: node
+queries:
+  pod:
+    cpu_usage: cpu
+    memory_usage: memory
+`,
+			message: "query usage.pod_cpu is missing",
+		},
+		{
+			name: "missing required label",
+			content: `
+labels:
+  namespace: namespace
+queries:
+  usage:
+    pod_cpu: pod_cpu
+    pod_memory: pod_memory
+    node_cpu: node_cpu
+    node_memory: node_memory
+  pod:
+    cpu_usage: cpu
+    memory_usage: memory
+  node:
+    cpu_usage: cpu
+    memory_usage: memory
+`,
+			message: `label "pod" is missing`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadQuerySet(QueryPresetKubePrometheusStack, writeQueryFile(t, test.content))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), test.message) {
+				t.Errorf("error = %v, want it to contain %q", err, test.message)
+			}
+		})
+	}
+}
+
+func TestLoadQuerySetRejectsMissingFile(t *testing.T) {
+	if _, err := loadQuerySet(QueryPresetKubePrometheusStack, filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing query file")
+	}
+}
+
+func TestQuerySetLabelMapping(t *testing.T) {
+	set, err := newQuerySet(&querySetFile{
+		Labels: map[string]string{"namespace": "exported_namespace", "pod": "pod_name", "container": "container_name", "node": "kubernetes_node"},
+		Queries: map[string]map[string]string{
+			queryGroupUsage: {
+				queryPodCPU:     `cpu{ {{label "namespace"}}={{quote .Namespace}}} by ({{label "pod"}}, {{label "container"}})`,
+				queryPodMemory:  `memory{ {{label "namespace"}}={{quote .Namespace}}}`,
+				queryNodeCPU:    `sum by ({{label "node"}}) (node_cpu)`,
+				queryNodeMemory: `sum by ({{label "node"}}) (node_memory)`,
+			},
+			queryGroupPod: {
+				"cpu_usage":    `cpu{ {{label "pod"}}={{quote .Pod}}}`,
+				"memory_usage": `memory{ {{label "pod"}}={{quote .Pod}}}`,
+			},
+			queryGroupNode: {
+				"cpu_usage":    `cpu{ {{label "node"}}={{quote .Node}}}`,
+				"memory_usage": `memory{ {{label "node"}}={{quote .Node}}}`,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create query set: %v", err)
+	}
+
+	tests := []struct {
+		group    string
+		name     string
+		data     queryData
+		expected string
+	}{
+		{
+			group:    queryGroupUsage,
+			name:     queryPodCPU,
+			data:     queryData{Namespace: "default"},
+			expected: `cpu{ exported_namespace="default"} by (pod_name, container_name)`,
+		},
+		{
+			group:    queryGroupPod,
+			name:     "cpu_usage",
+			data:     queryData{Pod: `api-"0"`},
+			expected: `cpu{ pod_name="api-\"0\""}`,
+		},
+		{
+			group:    queryGroupNode,
+			name:     "memory_usage",
+			data:     queryData{Node: "node-1"},
+			expected: `memory{ kubernetes_node="node-1"}`,
+		},
+	}
+	for _, test := range tests {
+		query, err := set.render(test.group, test.name, test.data)
+		if err != nil {
+			t.Errorf("failed to render %s.%s: %v", test.group, test.name, err)
+			continue
+		}
+		if query != test.expected {
+			t.Errorf("%s.%s = %s, want %s", test.group, test.name, query, test.expected)
+		}
+	}
+
+	if _, err := set.render(queryGroupPod, "gpu_usage", queryData{}); err == nil {
+		t.Error("expected an error for an undefined query")
+	}
+	if label := set.label("node"); label != "kubernetes_node" {
+		t.Errorf("node label = %s, want kubernetes_node", label)
+	}
+}
@@ -0,0 +1,532 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/metrics"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	revisionAnnotation    = "deployment.kubernetes.io/revision"
+	changeCauseAnnotation = "kubernetes.io/change-cause"
+)
+
+// GetWorkloads lists the deployments, statefulsets, daemonsets and jobs of the namespace. Pods are
+// attributed to them by their controller ownerReference, through the ReplicaSet for deployments.
+// Jobs created by a CronJob carry its name, they are listed on their own.
+func (c *KubernetesClient) GetWorkloads(ctx context.Context, namespace string) ([]metrics.Workload, error) {
+	var deployments *appsv1.DeploymentList
+	var statefulSets *appsv1.StatefulSetList
+	var daemonSets *appsv1.DaemonSetList
+	var jobs *batchv1.JobList
+	var replicaSets *appsv1.ReplicaSetList
+	var pods *corev1.PodList
+	var usage map[containerKey]resourceUsage
+
+	apps := c.clientset.AppsV1()
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		deployments, err = apps.Deployments(namespace).List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		statefulSets, err = apps.StatefulSets(namespace).List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		daemonSets, err = apps.DaemonSets(namespace).List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		jobs, err = c.clientset.BatchV1().Jobs(namespace).List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		replicaSets, err = apps.ReplicaSets(namespace).List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		pods, err = c.clientset.CoreV1().Pods(namespace).List(groupCtx, metav1.ListOptions{})
+		return err
+	})
+	group.Go(func() error {
+		usage = c.namespaceUsage(groupCtx, namespace)
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	workloads := make([]*metrics.Workload, 0, len(deployments.Items)+len(statefulSets.Items)+len(daemonSets.Items)+len(jobs.Items))
+	byUID := make(map[types.UID]*metrics.Workload, cap(workloads))
+	add := func(uid types.UID, workload *metrics.Workload) {
+		if usage != nil {
+			trackWorkloadUsage(workload)
+		}
+		workloads = append(workloads, workload)
+		byUID[uid] = workload
+	}
+	for i := range deployments.Items {
+		add(deployments.Items[i].UID, deploymentWorkload(&deployments.Items[i]))
+	}
+	for i := range statefulSets.Items {
+		add(statefulSets.Items[i].UID, statefulSetWorkload(&statefulSets.Items[i]))
+	}
+	for i := range daemonSets.Items {
+		add(daemonSets.Items[i].UID, daemonSetWorkload(&daemonSets.Items[i]))
+	}
+	for i := range jobs.Items {
+		add(jobs.Items[i].UID, jobWorkload(&jobs.Items[i]))
+	}
+
+	owners := replicaSetOwners(replicaSets.Items)
+	for i := range pods.Items {
+		if workload, ok := byUID[podWorkloadUID(&pods.Items[i], owners)]; ok {
+			addWorkloadPod(workload, podMetrics(&pods.Items[i], usage))
+		}
+	}
+
+	sort.SliceStable(workloads, func(i, j int) bool {
+		return workloads[i].Name < workloads[j].Name
+	})
+	result := make([]metrics.Workload, 0, len(workloads))
+	for _, workload := range workloads {
+		result = append(result, *workload)
+	}
+	return result, nil
+}
+
+// GetWorkload returns a single workload with its pods, deployments also get their revision history.
+// The strategy of a job is its completion mode.
+func (c *KubernetesClient) GetWorkload(ctx context.Context, namespace, kind, name string) (*metrics.WorkloadDetail, error) {
+	apps := c.clientset.AppsV1()
+
+	var uid types.UID
+	var workload *metrics.Workload
+	var selector *metav1.LabelSelector
+	var strategy string
+	var deployment *appsv1.Deployment
+	var err error
+	switch strings.ToLower(kind) {
+	case "deployment":
+		deployment, err = apps.Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			uid, workload, selector, strategy = deployment.UID, deploymentWorkload(deployment), deployment.Spec.Selector, string(deployment.Spec.Strategy.Type)
+		}
+	case "statefulset":
+		var statefulSet *appsv1.StatefulSet
+		statefulSet, err = apps.StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			uid, workload, selector, strategy = statefulSet.UID, statefulSetWorkload(statefulSet), statefulSet.Spec.Selector, string(statefulSet.Spec.UpdateStrategy.Type)
+		}
+	case "daemonset":
+		var daemonSet *appsv1.DaemonSet
+		daemonSet, err = apps.DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			uid, workload, selector, strategy = daemonSet.UID, daemonSetWorkload(daemonSet), daemonSet.Spec.Selector, string(daemonSet.Spec.UpdateStrategy.Type)
+		}
+	case "job":
+		var job *batchv1.Job
+		job, err = c.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			uid, workload, selector, strategy = job.UID, jobWorkload(job), job.Spec.Selector, jobCompletionMode(job)
+		}
+	default:
+		return nil, metrics.ErrUnknownWorkloadKind
+	}
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: %s %s/%s", metrics.ErrNotFound, kind, namespace, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector of %s %s/%s: %w", kind, namespace, name, err)
+	}
+	listOptions := metav1.ListOptions{LabelSelector: labelSelector.String()}
+
+	var pods *corev1.PodList
+	replicaSets := &appsv1.ReplicaSetList{}
+	var usage map[containerKey]resourceUsage
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		pods, err = c.clientset.CoreV1().Pods(namespace).List(groupCtx, listOptions)
+		return err
+	})
+	if deployment != nil {
+		group.Go(func() error {
+			var err error
+			replicaSets, err = apps.ReplicaSets(namespace).List(groupCtx, listOptions)
+			return err
+		})
+	}
+	group.Go(func() error {
+		usage = c.namespaceUsage(groupCtx, namespace)
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	detail := &metrics.WorkloadDetail{
+		Selector:  labelSelector.String(),
+		Strategy:  strategy,
+		Revisions: make([]metrics.WorkloadRevision, 0),
+		PodList:   make([]metrics.PodMetrics, 0),
+	}
+
+	if usage != nil {
+		trackWorkloadUsage(workload)
+	}
+	// The selector may match pods of other controllers, only the owned ones are counted
+	owners := replicaSetOwners(replicaSets.Items)
+	for i := range pods.Items {
+		if podWorkloadUID(&pods.Items[i], owners) != uid {
+			continue
+		}
+		pod := podMetrics(&pods.Items[i], usage)
+		addWorkloadPod(workload, pod)
+		detail.PodList = append(detail.PodList, pod)
+	}
+	if deployment != nil {
+		detail.Revisions = deploymentRevisions(deployment, replicaSets.Items)
+	}
+
+	detail.Workload = *workload
+	return detail, nil
+}
+
+// replicaSetOwners maps each ReplicaSet to the Deployment controlling it
+func replicaSetOwners(replicaSets []appsv1.ReplicaSet) map[types.UID]types.UID {
+	owners := make(map[types.UID]types.UID, len(replicaSets))
+	for i := range replicaSets {
+		if controller := metav1.GetControllerOfNoCopy(&replicaSets[i]); controller != nil && controller.Kind == "Deployment" {
+			owners[replicaSets[i].UID] = controller.UID
+		}
+	}
+	return owners
+}
+
+func podWorkloadUID(pod *corev1.Pod, replicaSetOwners map[types.UID]types.UID) types.UID {
+	controller := metav1.GetControllerOfNoCopy(pod)
+	if controller == nil {
+		return ""
+	}
+	if controller.Kind == "ReplicaSet" {
+		return replicaSetOwners[controller.UID]
+	}
+	return controller.UID
+}
+
+// trackWorkloadUsage starts the usage of the workload at zero, it stays nil when the
+// usage of the namespace is unknown
+func trackWorkloadUsage(workload *metrics.Workload) {
+	cpu, memory := int64(0), int64(0)
+	workload.CPUUsage = &cpu
+	workload.MemoryUsage = &memory
+}
+
+// addWorkloadPod adds the usage of the pod, finished pods don't hold their requests and limits anymore
+func addWorkloadPod(workload *metrics.Workload, pod metrics.PodMetrics) {
+	workload.Pods++
+	workload.RestartCount += pod.RestartCount
+	if workload.CPUUsage != nil {
+		*workload.CPUUsage += pod.CPUUsage
+		*workload.MemoryUsage += pod.MemoryUsage
+	}
+	if pod.Status == string(corev1.PodSucceeded) || pod.Status == string(corev1.PodFailed) {
+		return
+	}
+	workload.CPUUsageRequest += pod.CPUUsageRequest
+	workload.CPUUsageLimit += pod.CPUUsageLimit
+	workload.MemoryUsageRequest += pod.MemoryUsageRequest
+	workload.MemoryUsageLimit += pod.MemoryUsageLimit
+}
+
+func newWorkload(kind string, meta metav1.ObjectMeta, template corev1.PodTemplateSpec) *metrics.Workload {
+	return &metrics.Workload{
+		Kind:       kind,
+		Name:       meta.Name,
+		Namespace:  meta.Namespace,
+		Images:     podSpecImages(&template.Spec),
+		Conditions: make([]metrics.Condition, 0),
+		CreatedAt:  meta.CreationTimestamp.Format(time.RFC3339),
+	}
+}
+
+func deploymentWorkload(deployment *appsv1.Deployment) *metrics.Workload {
+	workload := newWorkload(metrics.WorkloadKindDeployment, deployment.ObjectMeta, deployment.Spec.Template)
+	workload.Replicas = replicasOrDefault(deployment.Spec.Replicas)
+	workload.ReadyReplicas = deployment.Status.ReadyReplicas
+	workload.AvailableReplicas = deployment.Status.AvailableReplicas
+	workload.UpdatedReplicas = deployment.Status.UpdatedReplicas
+	for _, condition := range deployment.Status.Conditions {
+		workload.Conditions = append(workload.Conditions, metricsCondition(
+			string(condition.Type), string(condition.Status), condition.Reason, condition.Message, condition.LastTransitionTime,
+		))
+	}
+	workload.RolloutStatus, workload.RolloutMessage = deploymentRolloutStatus(deployment, workload.Replicas)
+	return workload
+}
+
+func statefulSetWorkload(statefulSet *appsv1.StatefulSet) *metrics.Workload {
+	workload := newWorkload(metrics.WorkloadKindStatefulSet, statefulSet.ObjectMeta, statefulSet.Spec.Template)
+	workload.Replicas = replicasOrDefault(statefulSet.Spec.Replicas)
+	workload.ReadyReplicas = statefulSet.Status.ReadyReplicas
+	workload.AvailableReplicas = statefulSet.Status.AvailableReplicas
+	workload.UpdatedReplicas = statefulSet.Status.UpdatedReplicas
+	for _, condition := range statefulSet.Status.Conditions {
+		workload.Conditions = append(workload.Conditions, metricsCondition(
+			string(condition.Type), string(condition.Status), condition.Reason, condition.Message, condition.LastTransitionTime,
+		))
+	}
+	workload.RolloutStatus, workload.RolloutMessage = statefulSetRolloutStatus(statefulSet, workload.Replicas)
+	return workload
+}
+
+func daemonSetWorkload(daemonSet *appsv1.DaemonSet) *metrics.Workload {
+	workload := newWorkload(metrics.WorkloadKindDaemonSet, daemonSet.ObjectMeta, daemonSet.Spec.Template)
+	workload.Replicas = daemonSet.Status.DesiredNumberScheduled
+	workload.ReadyReplicas = daemonSet.Status.NumberReady
+	workload.AvailableReplicas = daemonSet.Status.NumberAvailable
+	workload.UpdatedReplicas = daemonSet.Status.UpdatedNumberScheduled
+	for _, condition := range daemonSet.Status.Conditions {
+		workload.Conditions = append(workload.Conditions, metricsCondition(
+			string(condition.Type), string(condition.Status), condition.Reason, condition.Message, condition.LastTransitionTime,
+		))
+	}
+	workload.RolloutStatus, workload.RolloutMessage = daemonSetRolloutStatus(daemonSet)
+	return workload
+}
+
+func jobWorkload(job *batchv1.Job) *metrics.Workload {
+	workload := newWorkload(metrics.WorkloadKindJob, job.ObjectMeta, job.Spec.Template)
+	parallelism := replicasOrDefault(job.Spec.Parallelism)
+	workload.Replicas = parallelism
+	if job.Spec.Completions != nil {
+		workload.Replicas = *job.Spec.Completions
+	}
+	if job.Status.Ready != nil {
+		workload.ReadyReplicas = *job.Status.Ready
+	}
+	workload.AvailableReplicas = job.Status.Active
+	workload.UpdatedReplicas = job.Status.Succeeded
+	for _, condition := range job.Status.Conditions {
+		workload.Conditions = append(workload.Conditions, metricsCondition(
+			string(condition.Type), string(condition.Status), condition.Reason, condition.Message, condition.LastTransitionTime,
+		))
+	}
+
+	status := &metrics.JobStatus{
+		Completions: job.Spec.Completions,
+		Parallelism: parallelism,
+		Active:      job.Status.Active,
+		Succeeded:   job.Status.Succeeded,
+		Failed:      job.Status.Failed,
+		Suspended:   job.Spec.Suspend != nil && *job.Spec.Suspend,
+	}
+	if controller := metav1.GetControllerOfNoCopy(job); controller != nil && controller.Kind == "CronJob" {
+		status.CronJob = controller.Name
+	}
+	if job.Status.StartTime != nil {
+		start := job.Status.StartTime.Time
+		status.StartTime = &start
+	}
+	if job.Status.CompletionTime != nil {
+		completion := job.Status.CompletionTime.Time
+		status.CompletionTime = &completion
+	}
+	workload.Job = status
+
+	workload.RolloutStatus, workload.RolloutMessage = jobRolloutStatus(job, status)
+	return workload
+}
+
+func jobCompletionMode(job *batchv1.Job) string {
+	if job.Spec.CompletionMode == nil {
+		return string(batchv1.NonIndexedCompletion)
+	}
+	return string(*job.Spec.CompletionMode)
+}
+
+// The rollout statuses follow "kubectl rollout status", a job is complete once it finished
+// successfully and failed once it ran out of retries or time
+
+func deploymentRolloutStatus(deployment *appsv1.Deployment, replicas int32) (string, string) {
+	status := deployment.Status
+	if deployment.Spec.Paused {
+		return metrics.RolloutPaused, "rollout is paused"
+	}
+	if deployment.Generation > status.ObservedGeneration {
+		return metrics.RolloutProgressing, "waiting for the spec update to be observed"
+	}
+	for _, condition := range status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return metrics.RolloutFailed, condition.Message
+		}
+	}
+	if status.UpdatedReplicas < replicas {
+		return metrics.RolloutProgressing, fmt.Sprintf("%d of %d new replicas have been updated", status.UpdatedReplicas, replicas)
+	}
+	if status.Replicas > status.UpdatedReplicas {
+		return metrics.RolloutProgressing, fmt.Sprintf("%d old replicas are pending termination", status.Replicas-status.UpdatedReplicas)
+	}
+	if status.AvailableReplicas < status.UpdatedReplicas {
+		return metrics.RolloutProgressing, fmt.Sprintf("%d of %d updated replicas are available", status.AvailableReplicas, status.UpdatedReplicas)
+	}
+	return metrics.RolloutComplete, ""
+}
+
+func statefulSetRolloutStatus(statefulSet *appsv1.StatefulSet, replicas int32) (string, string) {
+	status := statefulSet.Status
+	if statefulSet.Generation > status.ObservedGeneration {
+		return metrics.RolloutProgressing, "waiting for the spec update to be observed"
+	}
+	if status.ReadyReplicas < replicas {
+		return metrics.RolloutProgressing, fmt.Sprintf("%d of %d pods are ready", status.ReadyReplicas, replicas)
+	}
+
+	strategy := statefulSet.Spec.UpdateStrategy
+	if strategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		if status.UpdateRevision != status.CurrentRevision {
+			return metrics.RolloutComplete, "pods are only updated when they are deleted"
+		}
+		return metrics.RolloutComplete, ""
+	}
+	if strategy.RollingUpdate != nil && strategy.RollingUpdate.Partition != nil && *strategy.RollingUpdate.Partition > 0 {
+		expected := max(replicas-*strategy.RollingUpdate.Partition, 0)
+		if status.UpdatedReplicas < expected {
+			return metrics.RolloutProgressing, fmt.Sprintf("%d of %d pods above the partition have been updated", status.UpdatedReplicas, expected)
+		}
+		return metrics.RolloutComplete, fmt.Sprintf("partitioned rollout of %d pods is complete", expected)
+	}
+	if status.UpdateRevision != status.CurrentRevision {
+		return metrics.RolloutProgressing, fmt.Sprintf("%d of %d pods have been updated to revision %s", status.UpdatedReplicas, replicas, status.UpdateRevision)
+	}
+	return metrics.RolloutComplete, ""
+}
+
+func daemonSetRolloutStatus(daemonSet *appsv1.DaemonSet) (string, string) {
+	status := daemonSet.Status
+	if daemonSet.Generation > status.ObservedGeneration {
+		return metrics.RolloutProgressing, "waiting for the spec update to be observed"
+	}
+	if daemonSet.Spec.UpdateStrategy.Type == appsv1.RollingUpdateDaemonSetStrategyType && status.UpdatedNumberScheduled < status.DesiredNumberScheduled {
+		return metrics.RolloutProgressing, fmt.Sprintf("%d of %d pods have been updated", status.UpdatedNumberScheduled, status.DesiredNumberScheduled)
+	}
+	if status.NumberAvailable < status.DesiredNumberScheduled {
+		return metrics.RolloutProgressing, fmt.Sprintf("%d of %d pods are available", status.NumberAvailable, status.DesiredNumberScheduled)
+	}
+	return metrics.RolloutComplete, ""
+}
+
+func jobRolloutStatus(job *batchv1.Job, status *metrics.JobStatus) (string, string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobFailed:
+			return metrics.RolloutFailed, condition.Message
+		case batchv1.JobComplete:
+			return metrics.RolloutComplete, ""
+		}
+	}
+	if status.Suspended {
+		return metrics.RolloutPaused, "job is suspended"
+	}
+	if status.Completions != nil {
+		return metrics.RolloutProgressing, fmt.Sprintf("%d of %d completions succeeded, %d pods active, %d failed", status.Succeeded, *status.Completions, status.Active, status.Failed)
+	}
+	return metrics.RolloutProgressing, fmt.Sprintf("%d pods active, %d failed", status.Active, status.Failed)
+}
+
+// deploymentRevisions returns the ReplicaSets of the deployment, newest revision first
+func deploymentRevisions(deployment *appsv1.Deployment, replicaSets []appsv1.ReplicaSet) []metrics.WorkloadRevision {
+	current := deployment.Annotations[revisionAnnotation]
+	revisions := make([]metrics.WorkloadRevision, 0)
+	for i := range replicaSets {
+		replicaSet := &replicaSets[i]
+		controller := metav1.GetControllerOfNoCopy(replicaSet)
+		if controller == nil || controller.UID != deployment.UID {
+			continue
+		}
+
+		revision, _ := strconv.ParseInt(replicaSet.Annotations[revisionAnnotation], 10, 64)
+		revisions = append(revisions, metrics.WorkloadRevision{
+			Revision:      revision,
+			ReplicaSet:    replicaSet.Name,
+			Images:        podSpecImages(&replicaSet.Spec.Template.Spec),
+			Replicas:      replicasOrDefault(replicaSet.Spec.Replicas),
+			ReadyReplicas: replicaSet.Status.ReadyReplicas,
+			ChangeCause:   replicaSet.Annotations[changeCauseAnnotation],
+			CreatedAt:     replicaSet.CreationTimestamp.Format(time.RFC3339),
+			Current:       current != "" && replicaSet.Annotations[revisionAnnotation] == current,
+		})
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision > revisions[j].Revision
+	})
+	return revisions
+}
+
+// podSpecImages lists the distinct images of the init and app containers
+func podSpecImages(spec *corev1.PodSpec) []string {
+	images := make([]string, 0, len(spec.InitContainers)+len(spec.Containers))
+	seen := make(map[string]struct{})
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			if _, ok := seen[container.Image]; ok {
+				continue
+			}
+			seen[container.Image] = struct{}{}
+			images = append(images, container.Image)
+		}
+	}
+	return images
+}
+
+// replicasOrDefault applies the API default of one replica
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+func metricsCondition(conditionType, status, reason, message string, lastTransition metav1.Time) metrics.Condition {
+	condition := metrics.Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+	if !lastTransition.IsZero() {
+		transition := lastTransition.Time
+		condition.LastTransitionTime = &transition
+	}
+	return condition
+}
@@ -0,0 +1,162 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"main/internal/config"
+	"main/pkg"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+)
+
+const (
+	memoryCacheMaxEntries = 5000
+	redisPingTimeout      = 2 * time.Second
+	redisKeyPrefix        = "prometheus:"
+)
+
+// QueryCache stores encoded query results until their TTL expires
+type QueryCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// NewQueryCache uses Redis when it's configured and reachable, otherwise results are cached in memory
+func NewQueryCache(lc fx.Lifecycle, env config.Env, logger pkg.Logger) QueryCache {
+	if env.RedisHost == "" {
+		return newMemoryCache()
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprint(env.RedisHost, ":", env.RedisPort),
+		Password: env.RedisPass,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), redisPingTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.Errorf("Redis at %s:%s is unreachable, caching Prometheus queries in memory: %v", env.RedisHost, env.RedisPort, err)
+		client.Close()
+		return newMemoryCache()
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return client.Close()
+		},
+	})
+	return &redisCache{client: client}
+}
+
+type redisCache struct {
+	client *redis.Client
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, redisKeyPrefix+key, value, ttl).Err()
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= memoryCacheMaxEntries {
+		for cachedKey, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, cachedKey)
+			}
+		}
+	}
+	if len(c.entries) < memoryCacheMaxEntries {
+		c.entries[key] = memoryCacheEntry{value: value, expires: now.Add(ttl)}
+	}
+	return nil
+}
+
+// cachedValue keeps the type of a model.Value so it can be decoded again
+type cachedValue struct {
+	Type  model.ValueType `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+func encodeValue(value model.Value) ([]byte, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(cachedValue{Type: value.Type(), Value: raw})
+}
+
+func decodeValue(data []byte) (model.Value, error) {
+	var cached cachedValue
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+
+	var value model.Value
+	switch cached.Type {
+	case model.ValVector:
+		value = &model.Vector{}
+	case model.ValMatrix:
+		value = &model.Matrix{}
+	case model.ValScalar:
+		value = &model.Scalar{}
+	case model.ValString:
+		value = &model.String{}
+	default:
+		return nil, fmt.Errorf("unsupported cached value type %q", cached.Type)
+	}
+	if err := json.Unmarshal(cached.Value, value); err != nil {
+		return nil, err
+	}
+
+	// Callers type-assert on the value types the API returns
+	switch typed := value.(type) {
+	case *model.Vector:
+		return *typed, nil
+	case *model.Matrix:
+		return *typed, nil
+	}
+	return value, nil
+}
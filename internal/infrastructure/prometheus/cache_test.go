@@ -0,0 +1,210 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"main/internal/config"
+	"main/pkg"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+func TestCachedValueRoundTrip(t *testing.T) {
+	timestamp := model.TimeFromUnix(1709290800)
+	metric := model.Metric{"__name__": "container_cpu_usage_seconds_total", "namespace": "default", "pod": "api-0"}
+
+	tests := []struct {
+		name  string
+		value model.Value
+	}{
+		{name: "scalar", value: &model.Scalar{Value: 0.25, Timestamp: timestamp}},
+		{name: "string", value: &model.String{Value: "up", Timestamp: timestamp}},
+		{name: "vector", value: model.Vector{
+			{Metric: metric, Value: 1.5, Timestamp: timestamp},
+			{Metric: model.Metric{"pod": "api-1"}, Value: 2, Timestamp: timestamp},
+		}},
+		{name: "empty vector", value: model.Vector{}},
+		{name: "matrix", value: model.Matrix{
+			{Metric: metric, Values: []model.SamplePair{{Timestamp: timestamp, Value: 1}, {Timestamp: timestamp.Add(time.Minute), Value: 2}}},
+			{Metric: model.Metric{"pod": "api-1"}, Values: []model.SamplePair{{Timestamp: timestamp, Value: 3}}},
+		}},
+		{name: "empty matrix", value: model.Matrix{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := encodeValue(test.value)
+			if err != nil {
+				t.Fatalf("failed to encode value: %v", err)
+			}
+			decoded, err := decodeValue(data)
+			if err != nil {
+				t.Fatalf("failed to decode value: %v", err)
+			}
+			if decoded.Type() != test.value.Type() {
+				t.Errorf("type = %s, want %s", decoded.Type(), test.value.Type())
+			}
+			// Callers type-assert on the value types the Prometheus API returns
+			if reflect.TypeOf(decoded) != reflect.TypeOf(test.value) {
+				t.Errorf("decoded into %T, want %T", decoded, test.value)
+			}
+			if !reflect.DeepEqual(decoded, test.value) {
+				t.Errorf("decoded = %v, want %v", decoded, test.value)
+			}
+		})
+	}
+}
+
+func TestDecodeValueRejectsInvalidData(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not json", data: "vector"},
+		{name: "unknown type", data: `{"type":"histogram","value":[]}`},
+		{name: "missing type", data: `{"value":[]}`},
+		{name: "value of another type", data: `{"type":"matrix","value":{"metric":{}}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if value, err := decodeValue([]byte(test.data)); err == nil {
+				t.Errorf("decoded %v, want an error", value)
+			}
+		})
+	}
+}
+
+func TestHistoryRange(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		start, end    time.Time
+		step          time.Duration
+		expectedStart time.Time
+		expectedEnd   time.Time
+	}{
+		{
+			name:          "aligned range is kept",
+			start:         base,
+			end:           base.Add(time.Hour),
+			step:          time.Minute,
+			expectedStart: base,
+			expectedEnd:   base.Add(time.Hour),
+		},
+		{
+			name:          "start is rounded down and end up",
+			start:         base.Add(20 * time.Second),
+			end:           base.Add(time.Hour + 10*time.Second),
+			step:          time.Minute,
+			expectedStart: base,
+			expectedEnd:   base.Add(time.Hour + time.Minute),
+		},
+		{
+			name:          "without step",
+			start:         base.Add(20 * time.Second),
+			end:           base.Add(time.Hour + 10*time.Second),
+			step:          0,
+			expectedStart: base.Add(20 * time.Second),
+			expectedEnd:   base.Add(time.Hour + 10*time.Second),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end := historyRange(test.start, test.end, test.step)
+			if !start.Equal(test.expectedStart) || !end.Equal(test.expectedEnd) {
+				t.Errorf("range = %s - %s, want %s - %s", start, end, test.expectedStart, test.expectedEnd)
+			}
+		})
+	}
+}
+
+func TestHistoryCacheKeyIsSharedWithinStep(t *testing.T) {
+	query := `sum(rate(container_cpu_usage_seconds_total[5m]))`
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	key := func(offset time.Duration, query string, step time.Duration) string {
+		start, end := historyRange(base.Add(offset), base.Add(time.Hour+offset), step)
+		return historyCacheKey(query, start, end, step)
+	}
+
+	first := key(5*time.Second, query, time.Minute)
+	if second := key(50*time.Second, query, time.Minute); second != first {
+		t.Errorf("calls within one step use different keys: %s and %s", first, second)
+	}
+	if next := key(time.Minute+5*time.Second, query, time.Minute); next == first {
+		t.Error("calls in different steps share a key")
+	}
+	if other := key(5*time.Second, query+" by (pod)", time.Minute); other == first {
+		t.Error("different queries share a key")
+	}
+	if coarser := key(5*time.Second, query, 5*time.Minute); coarser == first {
+		t.Error("different steps share a key")
+	}
+}
+
+func TestValueCacheKeyIsSharedWithinAlignment(t *testing.T) {
+	query := `up`
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	first := valueCacheKey(query, instantQueryTime(base.Add(time.Second)))
+	if second := valueCacheKey(query, instantQueryTime(base.Add(instantQueryAlignment-time.Second))); second != first {
+		t.Errorf("calls within one alignment use different keys: %s and %s", first, second)
+	}
+	if next := valueCacheKey(query, instantQueryTime(base.Add(instantQueryAlignment))); next == first {
+		t.Error("calls in different alignments share a key")
+	}
+}
+
+func TestHistoryCacheTTL(t *testing.T) {
+	tests := []struct {
+		step     time.Duration
+		expected time.Duration
+	}{
+		{step: 0, expected: minRangeCacheTTL},
+		{step: time.Second, expected: minRangeCacheTTL},
+		{step: time.Minute, expected: time.Minute},
+		{step: time.Hour, expected: maxRangeCacheTTL},
+	}
+
+	for _, test := range tests {
+		if ttl := historyCacheTTL(test.step); ttl != test.expected {
+			t.Errorf("historyCacheTTL(%s) = %s, want %s", test.step, ttl, test.expected)
+		}
+	}
+}
+
+func TestGetMetricHistoryUsesCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"pod":"api-0"},"values":[[1709290800,"1"]]}]}}`)
+	}))
+	defer server.Close()
+
+	env := config.Env{PrometheusHost: server.URL}
+	client := NewPrometheusClient(pkg.GetLogger(env), env, newMemoryCache())
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, offset := range []time.Duration{5 * time.Second, 50 * time.Second} {
+		value, err := client.GetMetricHistoryContext(context.Background(), "up", base.Add(offset), base.Add(time.Hour+offset), time.Minute)
+		if err != nil {
+			t.Fatalf("failed to get history: %v", err)
+		}
+		matrix, ok := value.(model.Matrix)
+		if !ok || len(matrix) != 1 || matrix[0].Metric["pod"] != "api-0" {
+			t.Fatalf("history = %#v, want the matrix returned by Prometheus", value)
+		}
+	}
+	if count := requests.Load(); count != 1 {
+		t.Errorf("Prometheus was queried %d times, want once", count)
+	}
+}
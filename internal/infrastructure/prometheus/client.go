@@ -2,6 +2,9 @@ package prometheus
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"main/internal/config"
 	"main/pkg"
 	"time"
@@ -10,19 +13,33 @@ import (
 	prometheusV1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	"go.uber.org/fx"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// Instant queries are evaluated at a time aligned to instantQueryAlignment,
+	// so requests within the same interval share one result
+	instantQueryAlignment = 15 * time.Second
+	minRangeCacheTTL      = 5 * time.Second
+	maxRangeCacheTTL      = 5 * time.Minute
+	// sharedQueryTimeout bounds a query that is shared between callers
+	sharedQueryTimeout = 30 * time.Second
 )
 
 type PrometheusClient struct {
 	logger pkg.Logger
 	client prometheusApi.Client
 	api    prometheusV1.API
+	cache  QueryCache
+	flight *singleflight.Group
 }
 
 var Module = fx.Module("prometheus",
+	fx.Provide(NewQueryCache),
 	fx.Provide(NewPrometheusClient),
 )
 
-func NewPrometheusClient(logger pkg.Logger, env config.Env) PrometheusClient {
+func NewPrometheusClient(logger pkg.Logger, env config.Env, cache QueryCache) PrometheusClient {
 	client, err := prometheusApi.NewClient(prometheusApi.Config{
 		Address: env.PrometheusHost,
 	})
@@ -34,31 +51,128 @@ func NewPrometheusClient(logger pkg.Logger, env config.Env) PrometheusClient {
 		logger: logger,
 		client: client,
 		api:    api,
+		cache:  cache,
+		flight: &singleflight.Group{},
 	}
 }
 
 func (client PrometheusClient) GetMetricValue(query string) (model.Value, error) {
-	value, warnings, err := client.api.Query(context.Background(), query, time.Now())
-	if err != nil {
-		return nil, err
-	}
-	if len(warnings) > 0 {
-		client.logger.Warnf("Prometheus query warnings: %v", warnings)
-	}
-	return value, nil
+	return client.GetMetricValueContext(context.Background(), query)
+}
+
+func (client PrometheusClient) GetMetricValueContext(ctx context.Context, query string) (model.Value, error) {
+	at := instantQueryTime(time.Now())
+	key := valueCacheKey(query, at)
+
+	return client.cached(ctx, key, instantQueryAlignment, func(ctx context.Context) (model.Value, error) {
+		value, warnings, err := client.api.Query(ctx, query, at)
+		if err != nil {
+			return nil, err
+		}
+		if len(warnings) > 0 {
+			client.logger.Warnf("Prometheus query warnings: %v", warnings)
+		}
+		return value, nil
+	})
 }
 
+// GetMetricHistory aligns the range to the step, the result is cached for about one step
 func (client PrometheusClient) GetMetricHistory(query string, start, end time.Time, step time.Duration) (model.Value, error) {
-	value, warnings, err := client.api.QueryRange(context.Background(), query, prometheusV1.Range{
-		Start: start,
-		End:   end,
-		Step:  step,
+	return client.GetMetricHistoryContext(context.Background(), query, start, end, step)
+}
+
+func (client PrometheusClient) GetMetricHistoryContext(ctx context.Context, query string, start, end time.Time, step time.Duration) (model.Value, error) {
+	start, end = historyRange(start, end, step)
+	key := historyCacheKey(query, start, end, step)
+
+	return client.cached(ctx, key, historyCacheTTL(step), func(ctx context.Context) (model.Value, error) {
+		value, warnings, err := client.api.QueryRange(ctx, query, prometheusV1.Range{
+			Start: start,
+			End:   end,
+			Step:  step,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(warnings) > 0 {
+			client.logger.Warnf("Prometheus query warnings: %v", warnings)
+		}
+		return value, nil
 	})
-	if err != nil {
-		return nil, err
+}
+
+// cached returns the cached result or runs the query once for all concurrent callers with the same key.
+// Cache errors are only logged, the query still runs.
+func (client PrometheusClient) cached(ctx context.Context, key string, ttl time.Duration, query func(ctx context.Context) (model.Value, error)) (model.Value, error) {
+	if data, ok, err := client.cache.Get(ctx, key); err != nil {
+		client.logger.Errorf("Failed to read cached Prometheus result: %v", err)
+	} else if ok {
+		if value, err := decodeValue(data); err == nil {
+			return value, nil
+		}
 	}
-	if len(warnings) > 0 {
-		client.logger.Warnf("Prometheus query warnings: %v", warnings)
+
+	result := client.flight.DoChan(key, func() (any, error) {
+		// The shared query must not be canceled by the caller that happened to start it
+		queryCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedQueryTimeout)
+		defer cancel()
+
+		value, err := query(queryCtx)
+		if err != nil {
+			return nil, err
+		}
+		if data, err := encodeValue(value); err == nil {
+			if err := client.cache.Set(queryCtx, key, data, ttl); err != nil {
+				client.logger.Errorf("Failed to cache Prometheus result: %v", err)
+			}
+		}
+		return value, nil
+	})
+
+	select {
+	case shared := <-result:
+		if shared.Err != nil {
+			return nil, shared.Err
+		}
+		return shared.Val.(model.Value), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// historyRange aligns the range to the step so concurrent dashboards share the cache key,
+// the end is rounded up so the newest samples are still included
+func historyRange(start, end time.Time, step time.Duration) (time.Time, time.Time) {
+	if step <= 0 {
+		return start, end
+	}
+	start = start.Truncate(step)
+	if aligned := end.Truncate(step); aligned.Before(end) {
+		end = aligned.Add(step)
 	}
-	return value, nil
+	return start, end
+}
+
+func historyCacheKey(query string, start, end time.Time, step time.Duration) string {
+	return fmt.Sprintf("history:%s:%d:%d:%d", queryHash(query), start.Unix(), end.Unix(), int64(step.Seconds()))
+}
+
+// historyCacheTTL keeps a range result for about one step
+func historyCacheTTL(step time.Duration) time.Duration {
+	return min(max(step, minRangeCacheTTL), maxRangeCacheTTL)
+}
+
+// instantQueryTime aligns the evaluation time of an instant query so that the
+// same query within one instantQueryAlignment interval has the same result
+func instantQueryTime(now time.Time) time.Time {
+	return now.Truncate(instantQueryAlignment)
+}
+
+func valueCacheKey(query string, at time.Time) string {
+	return fmt.Sprintf("value:%s:%d", queryHash(query), at.Unix())
+}
+
+func queryHash(query string) string {
+	hash := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(hash[:])
 }
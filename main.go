@@ -6,5 +6,7 @@ import (
 )
 
 func main() {
-	log.Fatal(cmd.StartApp())
+	if err := cmd.StartApp(); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,8 +1,13 @@
 package handler
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
 
 type RequestHandler interface {
 	Group(path string) gin.IRoutes
 	Run(addr string) error
+	Shutdown(ctx context.Context) error
 }
@@ -1,14 +1,20 @@
 package handler
 
 import (
+	"context"
 	"main/pkg"
+	"net"
+	"net/http"
+	"sync"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 type RequestHandlerImpl struct {
-	gin *gin.Engine
+	gin    *gin.Engine
+	mu     sync.Mutex
+	server *http.Server
 }
 
 func (rh *RequestHandlerImpl) Group(path string) gin.IRoutes {
@@ -16,7 +22,32 @@ func (rh *RequestHandlerImpl) Group(path string) gin.IRoutes {
 }
 
 func (rh *RequestHandlerImpl) Run(addr string) error {
-	return rh.gin.Run(addr)
+	// Long living requests such as event streams watch the request context,
+	// it is canceled as soon as the shutdown starts so they don't hold it back
+	baseCtx, cancel := context.WithCancel(context.Background())
+
+	rh.mu.Lock()
+	rh.server = &http.Server{
+		Addr:        addr,
+		Handler:     rh.gin.Handler(),
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+	rh.server.RegisterOnShutdown(cancel)
+	server := rh.server
+	rh.mu.Unlock()
+
+	return server.ListenAndServe()
+}
+
+func (rh *RequestHandlerImpl) Shutdown(ctx context.Context) error {
+	rh.mu.Lock()
+	server := rh.server
+	rh.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
 }
 
 func NewRequestHandler(logger pkg.Logger) RequestHandler {
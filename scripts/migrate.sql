@@ -13,6 +13,61 @@ CREATE TABLE IF NOT EXISTS events (
     count INTEGER
 );
 
+ALTER TABLE events ADD COLUMN IF NOT EXISTS source VARCHAR(50) NOT NULL DEFAULT 'kubernetes';
+
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS involved_kind VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS involved_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS involved_namespace VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS involved_uid VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS involved_api_version VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS involved_field_path VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS owner_kind VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS owner_name VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Events stored before the involved object was split keep only "Kind/Name"
+UPDATE events
+SET involved_kind = split_part(involved_object, '/', 1),
+    involved_name = split_part(involved_object, '/', 2),
+    involved_namespace = namespace
+WHERE involved_kind = '' AND involved_object LIKE '%/%';
+
+-- Events stored before the timestamps were converted properly have a zero last_timestamp,
+-- which the time filters and the pagination cursor rely on
+UPDATE events
+SET last_timestamp = first_timestamp
+WHERE (last_timestamp IS NULL OR last_timestamp < TIMESTAMP '1970-01-02')
+    AND first_timestamp >= TIMESTAMP '1970-01-02';
+
+CREATE INDEX IF NOT EXISTS events_last_timestamp_id_idx ON events (last_timestamp, id);
+CREATE INDEX IF NOT EXISTS events_namespace_last_timestamp_idx ON events (namespace, last_timestamp);
+CREATE INDEX IF NOT EXISTS events_involved_idx ON events (involved_kind, involved_name);
+CREATE INDEX IF NOT EXISTS events_owner_idx ON events (owner_name, owner_kind);
+
+-- Occurrences per namespace, reason and 5 minute bucket. Aggregated events update a single
+-- row of events, so the increase of their count is recorded here when they are saved.
+CREATE TABLE IF NOT EXISTS event_rate_buckets (
+    namespace VARCHAR(255) NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    bucket TIMESTAMP NOT NULL,
+    occurrences BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (namespace, reason, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS event_rate_buckets_bucket_idx ON event_rate_buckets (bucket);
+
+-- Buckets seeded from zero timestamps would make the history look years long
+DELETE FROM event_rate_buckets WHERE bucket < TIMESTAMP '1970-01-02';
+
+-- Seed the rates once from the events stored so far, the whole count is attributed to the last occurrence
+INSERT INTO event_rate_buckets (namespace, reason, bucket, occurrences)
+SELECT namespace, reason, date_bin('5 minutes', last_timestamp, TIMESTAMP '2000-01-01'), SUM(GREATEST(count, 1))
+FROM events
+WHERE namespace IS NOT NULL AND reason IS NOT NULL AND last_timestamp IS NOT NULL
+    AND NOT EXISTS (SELECT 1 FROM event_rate_buckets)
+GROUP BY 1, 2, 3
+ON CONFLICT DO NOTHING;
+
 CREATE TABLE IF NOT EXISTS watched_namespaces (
     namespace VARCHAR(255) PRIMARY KEY,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP